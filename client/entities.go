@@ -1,6 +1,9 @@
 package client
 
-import "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
+)
 
 type ContactInfo struct {
 	Name string
@@ -8,16 +11,7 @@ type ContactInfo struct {
 }
 
 func GetDefaultContactInfoSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"name": {
-				Type: schema.TypeString,
-			},
-			"url": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(ContactInfo{})
 }
 
 type ReferenceInfo struct {
@@ -26,16 +20,7 @@ type ReferenceInfo struct {
 }
 
 func GetDefaultReferenceInfoSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"documentation_url": {
-				Type: schema.TypeString,
-			},
-			"github_url": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(ReferenceInfo{})
 }
 
 type FolderMount struct {
@@ -45,19 +30,7 @@ type FolderMount struct {
 }
 
 func GetDefaultFolderMountSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"folder_mount": {
-				Type: schema.TypeBool,
-			},
-			"source_folder": {
-				Type: schema.TypeString,
-			},
-			"destination_folder": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(FolderMount{})
 }
 
 type AuthenticationParameterSchema struct {
@@ -65,13 +38,7 @@ type AuthenticationParameterSchema struct {
 }
 
 func GetDefaultAuthenticationParameterSchemaSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"type": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(AuthenticationParameterSchema{})
 }
 
 type AuthenticationParameter struct {
@@ -87,38 +54,7 @@ type AuthenticationParameter struct {
 }
 
 func GetDefaultAuthenticationParameterSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"description": {
-				Type: schema.TypeString,
-			},
-			"id": {
-				Type: schema.TypeString,
-			},
-			"name": {
-				Type: schema.TypeString,
-			},
-			"example": {
-				Type: schema.TypeString,
-			},
-			"multiline": {
-				Type: schema.TypeBool,
-			},
-			"required": {
-				Type: schema.TypeBool,
-			},
-			"in": {
-				Type: schema.TypeString,
-			},
-			"schema": {
-				Type: schema.TypeList,
-				Elem: GetDefaultAuthenticationParameterSchemaSchema(),
-			},
-			"scheme": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(AuthenticationParameter{})
 }
 
 type Authentication struct {
@@ -129,39 +65,11 @@ type Authentication struct {
 	TokenUri     string `json:"token_uri"`
 	RefreshUri   string `json:"refresh_uri"`
 	ClientId     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
+	ClientSecret string `json:"client_secret" tf:"sensitive"`
 }
 
 func GetDefaultAuthenticationSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"type": {
-				Type: schema.TypeString,
-			},
-			"required": {
-				Type: schema.TypeBool,
-			},
-			"parameters": {
-				Type: schema.TypeList,
-				Elem: GetDefaultAuthenticationParameterSchema(),
-			},
-			"redirect_uri": {
-				Type: schema.TypeString,
-			},
-			"token_uri": {
-				Type: schema.TypeString,
-			},
-			"refresh_uri": {
-				Type: schema.TypeString,
-			},
-			"client_id": {
-				Type: schema.TypeString,
-			},
-			"client_secret": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(Authentication{})
 }
 
 type Version struct {
@@ -170,24 +78,15 @@ type Version struct {
 }
 
 func GetDefaultVersionSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"version": {
-				Type: schema.TypeString,
-			},
-			"id": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(Version{})
 }
 
 type AppAuthentication struct {
-	Name           string
-	IsValid        bool `json:"is_valid"`
-	Id             string
+	Name           string `description:"The App name to link this authentication config to. This must match an existing App's name"`
+	IsValid        bool   `json:"is_valid"`
+	Id             string `description:"The App Id of the App to link this authentication config to. Found from the name and the version of the app when not set"`
 	Link           string
-	AppVersion     string `json:"app_version"`
+	AppVersion     string `json:"app_version" description:"The version of the App to link this authentication config to. Must be one of the versions available in Shuffle"`
 	SharingConfig  string `json:"sharing_config"`
 	Generated      bool
 	Downloaded     bool
@@ -201,11 +100,11 @@ type AppAuthentication struct {
 	Description    string
 	Environment    string
 	SmallImage     string        `json:"small_image"`
-	LargeImage     string        `json:"large_image"`
+	LargeImage     string        `json:"large_image" description:"The base64 string for the image to display. Format: data:image/png;base64,THE_BASE64. The image of the app is used when not set"`
 	ContactInfo    ContactInfo   `json:"contact_info"`
 	ReferenceInfo  ReferenceInfo `json:"reference_info"`
 	FolderMount    FolderMount   `json:"folder_mount"`
-	Actions        interface{}
+	Actions        interface{}   `description:"The actions of the App, JSON encoded"`
 	Authentication Authentication
 	Tags           []string
 	Categories     []string
@@ -223,152 +122,16 @@ type AppAuthentication struct {
 }
 
 func GetDefaultAppAuthenticationSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"name": {
-				Type: schema.TypeString,
-				// Computed: true,
-				// Required:    true,
-				Description: "The App name to link this authentication config to. This must match an existing App's name",
-			},
-			"id": {
-				Type: schema.TypeString,
-				// Computed: true,
-				// Optional:    true,
-				Description: "The App Id of the App to link this authentication config to",
-			},
-			"large_image": {
-				Type: schema.TypeString,
-				// Computed: true,
-				// Optional:    true,
-				Description: "The base64 string for the image to display. Format: data:image/png;base64,THE_BASE64",
-			},
-			"is_valid": {
-				Type: schema.TypeBool,
-			},
-			"link": {
-				Type: schema.TypeString,
-			},
-			"app_version": {
-				Type: schema.TypeString,
-			},
-			"sharing_config": {
-				Type: schema.TypeString,
-			},
-			"generated": {
-				Type: schema.TypeBool,
-			},
-			"downloaded": {
-				Type: schema.TypeBool,
-			},
-			"sharing": {
-				Type: schema.TypeBool,
-			},
-			"verified": {
-				Type: schema.TypeBool,
-			},
-			"invalid": {
-				Type: schema.TypeBool,
-			},
-			"activated": {
-				Type: schema.TypeBool,
-			},
-			"tested": {
-				Type: schema.TypeBool,
-			},
-			"hash": {
-				Type: schema.TypeString,
-			},
-			"private_id": {
-				Type: schema.TypeString,
-			},
-			"description": {
-				Type: schema.TypeString,
-			},
-			"environment": {
-				Type: schema.TypeString,
-			},
-			"small_image": {
-				Type: schema.TypeString,
-			},
-			"tags": {
-				Type: schema.TypeString,
-			},
-			"categories": {
-				Type: schema.TypeString,
-			},
-			"created": {
-				Type: schema.TypeInt,
-			},
-			"edited": {
-				Type: schema.TypeInt,
-			},
-			"last_runtime": {
-				Type: schema.TypeInt,
-			},
-			"loop_versions": {
-				Type: schema.TypeString,
-			},
-			"owner": {
-				Type: schema.TypeString,
-			},
-			"public": {
-				Type: schema.TypeBool,
-			},
-			"reference_org": {
-				Type: schema.TypeString,
-			},
-			"reference_url": {
-				Type: schema.TypeString,
-			},
-			"action_file_path": {
-				Type: schema.TypeString,
-			},
-			"documentation": {
-				Type: schema.TypeString,
-			},
-			"versions": {
-				Type: schema.TypeList,
-				Elem: GetDefaultVersionSchema(),
-			},
-			"contact_info": {
-				Type: schema.TypeList,
-				Elem: GetDefaultContactInfoSchema(),
-			},
-			"reference_info": {
-				Type: schema.TypeList,
-				Elem: GetDefaultReferenceInfoSchema(),
-			},
-			"folder_mount": {
-				Type: schema.TypeList,
-				Elem: GetDefaultFolderMountSchema(),
-			},
-			"authentication": {
-				Type: schema.TypeList,
-				Elem: GetDefaultAuthenticationSchema(),
-			},
-		},
-	}
+	return utils.SchemaFromStruct(AppAuthentication{})
 }
 
 type Field struct {
 	Key   string
-	Value string
+	Value string `tf:"sensitive"`
 }
 
 func GetDefaultFieldSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"key": {
-				Type: schema.TypeString,
-				// Computed: true,
-			},
-			"value": {
-				Type: schema.TypeString,
-				// Computed: true,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(Field{})
 }
 
 type AppUsage struct {
@@ -377,27 +140,15 @@ type AppUsage struct {
 }
 
 func GetDefaultAppUsageSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"workflow_id": {
-				Type: schema.TypeString,
-			},
-			"nodes": {
-				Type: schema.TypeList,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
-			},
-		},
-	}
+	return utils.SchemaFromStruct(AppUsage{})
 }
 
 type App struct {
 	Active            bool
-	Label             string
+	Label             string `description:"The text to display in the Shuffle UI"`
 	Id                string
-	App               AppAuthentication
-	Fields            []Field
+	App               AppAuthentication `description:"A block for the app authentication settings"`
+	Fields            []Field           `description:"This is a list of all the required fields for this app authentication. The name of the fields must match the names in the authentication parameters and there must be the same number of parameters and fields."`
 	Usage             []AppUsage
 	WorkflowCount     int    `json:"workflow_count"`
 	NodeCount         int    `json:"node_count"`
@@ -411,61 +162,7 @@ type App struct {
 }
 
 func GetDefaultAppSchema() *schema.Resource {
-	return &schema.Resource{
-		Schema: map[string]*schema.Schema{
-			"active": {
-				Type: schema.TypeBool,
-			},
-			"label": {
-				Type:        schema.TypeString,
-				Description: "The text to display in the Shuffle UI",
-			},
-			"id": {
-				Type: schema.TypeString,
-			},
-			"app": {
-				Type:        schema.TypeList,
-				Description: "A block for the app authentication settings",
-				Elem:        GetDefaultAppAuthenticationSchema(),
-			},
-			"fields": {
-				Type:        schema.TypeList,
-				Description: "This is a list of all the required fields for this app authentication. The name of the fields must match the names in the authentication parameters and there must be the same number of parameters and fields.",
-				Elem:        GetDefaultFieldSchema(),
-			},
-			"usage": {
-				Type: schema.TypeList,
-				Elem: GetDefaultAppUsageSchema(),
-			},
-			"workflow_count": {
-				Type: schema.TypeInt,
-			},
-			"node_count": {
-				Type: schema.TypeInt,
-			},
-			"org_id": {
-				Type: schema.TypeString,
-			},
-			"created": {
-				Type: schema.TypeInt,
-			},
-			"edited": {
-				Type: schema.TypeInt,
-			},
-			"defined": {
-				Type: schema.TypeBool,
-			},
-			"type": {
-				Type: schema.TypeString,
-			},
-			"encrypted": {
-				Type: schema.TypeBool,
-			},
-			"referenceworkflow": {
-				Type: schema.TypeString,
-			},
-		},
-	}
+	return utils.SchemaFromStruct(App{})
 }
 
 type GetAppResponse struct {
@@ -477,3 +174,119 @@ type CreateOrUpdateResponse struct {
 	Success bool
 	Id      string
 }
+
+type WorkflowPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func GetDefaultWorkflowPositionSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowPosition{})
+}
+
+type WorkflowParameter struct {
+	Name  string `json:"name" description:"The name of the parameter, as defined by the app action"`
+	Value string `json:"value" description:"The value of the parameter. Can reference previous nodes, i.e $exec.field"`
+}
+
+func GetDefaultWorkflowParameterSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowParameter{})
+}
+
+type WorkflowAction struct {
+	Id               string              `json:"id" description:"A unique ID (UUID) for this node. Used by start and the branches to reference it"`
+	AppName          string              `json:"app_name" description:"The name of the app executing this action (i.e Shuffle Tools)"`
+	AppVersion       string              `json:"app_version"`
+	AppId            string              `json:"app_id"`
+	Name             string              `json:"name" description:"The name of the app action to run (i.e repeat_back_to_me)"`
+	Label            string              `json:"label" description:"The text to display on the node in the Shuffle UI"`
+	Environment      string              `json:"environment" description:"The environment to run the action in (i.e Shuffle or cloud)"`
+	AuthenticationId string              `json:"authentication_id" description:"The ID of the app authentication to use for this action"`
+	IsStartNode      bool                `json:"isStartNode" tf:"-"`
+	Parameters       []WorkflowParameter `json:"parameters"`
+	Position         WorkflowPosition    `json:"position"`
+}
+
+func GetDefaultWorkflowActionSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowAction{})
+}
+
+type WorkflowTrigger struct {
+	Id          string              `json:"id" description:"A unique ID (UUID) for this trigger. Used by the branches to reference it"`
+	AppName     string              `json:"app_name" description:"The name of the trigger app (i.e Webhook or Schedule)"`
+	AppVersion  string              `json:"app_version"`
+	Name        string              `json:"name"`
+	Label       string              `json:"label" description:"The text to display on the node in the Shuffle UI"`
+	TriggerType string              `json:"trigger_type" description:"The type of trigger (i.e WEBHOOK, SCHEDULE, USERINPUT or SUBFLOW)"`
+	Status      string              `json:"status"`
+	Environment string              `json:"environment"`
+	Parameters  []WorkflowParameter `json:"parameters"`
+	Position    WorkflowPosition    `json:"position"`
+}
+
+func GetDefaultWorkflowTriggerSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowTrigger{})
+}
+
+type WorkflowBranchCondition struct {
+	Source      WorkflowParameter `json:"source"`
+	Condition   WorkflowParameter `json:"condition"`
+	Destination WorkflowParameter `json:"destination"`
+}
+
+func GetDefaultWorkflowBranchConditionSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowBranchCondition{})
+}
+
+type WorkflowBranch struct {
+	Id            string                    `json:"id"`
+	SourceId      string                    `json:"source_id" description:"The ID of the action or trigger this branch starts from"`
+	DestinationId string                    `json:"destination_id" description:"The ID of the action this branch leads to"`
+	Conditions    []WorkflowBranchCondition `json:"conditions"`
+}
+
+func GetDefaultWorkflowBranchSchema() *schema.Resource {
+	return utils.SchemaFromStruct(WorkflowBranch{})
+}
+
+// WorkflowDefinition is the part of a workflow describing its graph. It is what
+// the `definition` attribute of the workflow resource holds as JSON.
+type WorkflowDefinition struct {
+	Start    string            `json:"start" description:"The ID of the action to start the workflow from"`
+	Actions  []WorkflowAction  `json:"actions" description:"A block per action (node) of the workflow"`
+	Triggers []WorkflowTrigger `json:"triggers" description:"A block per trigger of the workflow"`
+	Branches []WorkflowBranch  `json:"branches" description:"A block per branch (edge) between two nodes of the workflow"`
+}
+
+type Workflow struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name" description:"The name of the workflow"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	OrgId       string   `json:"org_id"`
+	WorkflowDefinition
+}
+
+func GetDefaultWorkflowSchema() *schema.Resource {
+	return utils.SchemaFromStruct(Workflow{})
+}
+
+type WorkflowResponse struct {
+	Success bool   `json:"success"`
+	Reason  string `json:"reason"`
+}
+
+type Org struct {
+	Id   string
+	Name string
+	Role string
+}
+
+type User struct {
+	Success   bool
+	Id        string
+	Username  string
+	Role      string
+	ActiveOrg Org `json:"active_org"`
+	Orgs      []Org
+}
@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/data_sources"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/resources"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
+)
+
+func readFixture(t *testing.T, name string, out interface{}) {
+	t.Helper()
+	body, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read the fixture: %s", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		t.Fatalf("failed to unmarshal the fixture %s: %s", name, err)
+	}
+}
+
+// setPayload sets the flattened payload in a ResourceData of the schema, every
+// attribute of the payload having to be in the schema
+func setPayload(t *testing.T, s map[string]*schema.Schema, v interface{}) *schema.ResourceData {
+	t.Helper()
+	d := schema.TestResourceDataRaw(t, s, map[string]interface{}{})
+	for key, value := range utils.Flatten(v) {
+		if _, ok := s[key]; !ok {
+			t.Errorf("%s is missing from the schema", key)
+			continue
+		}
+		if key == "id" {
+			continue
+		}
+		if err := d.Set(key, value); err != nil {
+			t.Errorf("failed to set %s: %s", key, err)
+		}
+	}
+	return d
+}
+
+func checkAttributes(t *testing.T, d *schema.ResourceData, expected map[string]interface{}) {
+	t.Helper()
+	for path, value := range expected {
+		if got := d.Get(path); !reflect.DeepEqual(got, value) {
+			t.Errorf("expected %s to be %#v, got %#v", path, value, got)
+		}
+	}
+}
+
+var expectedAppAuthAttributes = map[string]interface{}{
+	"label":                    "Mail",
+	"active":                   true,
+	"encrypted":                true,
+	"defined":                  true,
+	"workflow_count":           1,
+	"org_id":                   "5e9d3d1c-2e1a-4f7e-9c85-0ed6c4a12d8c",
+	"fields.1.key":             "certificate",
+	"usage.0.workflow_id":      "3b7f6d5c-1a2e-4f9b-8c7d-6e5f4a3b2c1d",
+	"usage.0.nodes.0":          "4a8e1c2d-5b6f-4e7a-9d8c-1b2a3c4d5e6f",
+	"app.0.name":               "Email",
+	"app.0.app_version":        "1.2.0",
+	"app.0.is_valid":           true,
+	"app.0.large_image":        "data:image/png;base64,BBBB",
+	"app.0.contact_info.0.url": "https://shuffler.io",
+	"app.0.reference_info.0.documentation_url":          "https://shuffler.io/docs",
+	"app.0.folder_mount.0.folder_mount":                 false,
+	"app.0.actions":                                     `[{"name":"send_email_smtp","parameters":[{"name":"recipient","required":true}]}]`,
+	"app.0.authentication.0.type":                       "api-key",
+	"app.0.authentication.0.parameters.0.name":          "smtp_host",
+	"app.0.authentication.0.parameters.0.required":      true,
+	"app.0.authentication.0.parameters.0.schema.0.type": "string",
+	"app.0.authentication.0.parameters.1.multiline":     true,
+	"app.0.versions.1.version":                          "1.1.0",
+	"app.0.loop_versions.1":                             "1.1.0",
+	"app.0.categories.0":                                "Email",
+	"app.0.created":                                     1650000000,
+	"app.0.documentation":                               "## Email",
+}
+
+func TestAppAuthenticationPayload(t *testing.T) {
+	var response client.GetAppResponse
+	readFixture(t, "app_authentications.json", &response)
+	if len(response.Data) != 1 {
+		t.Fatalf("expected one authentication, got %d", len(response.Data))
+	}
+	app := response.Data[0]
+
+	d := setPayload(t, data_sources.DataSourceAppAuthentication().Schema, app)
+	checkAttributes(t, d, expectedAppAuthAttributes)
+
+	d = schema.TestResourceDataRaw(t, data_sources.DataSourceAllAppAuthentication().Schema, map[string]interface{}{})
+	if err := d.Set("all_app_auths", []interface{}{utils.Flatten(app)}); err != nil {
+		t.Fatalf("failed to set all_app_auths: %s", err)
+	}
+	expected := make(map[string]interface{}, len(expectedAppAuthAttributes))
+	for path, value := range expectedAppAuthAttributes {
+		expected["all_app_auths.0."+path] = value
+	}
+	checkAttributes(t, d, expected)
+
+	// The resource holds the app found in the catalog in its app block
+	appSchema := resources.ResourceAppAuthentication().Schema["app"].Elem.(*schema.Resource).Schema
+	d = setPayload(t, appSchema, app.App)
+	checkAttributes(t, d, map[string]interface{}{
+		"name":                               "Email",
+		"app_version":                        "1.2.0",
+		"authentication.0.parameters.1.name": "certificate",
+		"versions.0.id":                      "8d2b1f4e6a7c9e0b1d3f5a7c9e1b3d5f",
+	})
+}
+
+func TestWorkflowPayload(t *testing.T) {
+	var workflow client.Workflow
+	readFixture(t, "workflow.json", &workflow)
+
+	d := setPayload(t, resources.ResourceWorkflow().Schema, workflow)
+	checkAttributes(t, d, map[string]interface{}{
+		"name":                         "Alert triage",
+		"description":                  "Triage the alerts",
+		"tags.0":                       "alerts",
+		"org_id":                       "5e9d3d1c-2e1a-4f7e-9c85-0ed6c4a12d8c",
+		"start":                        "4a8e1c2d-5b6f-4e7a-9d8c-1b2a3c4d5e6f",
+		"actions.0.app_name":           "Shuffle Tools",
+		"actions.0.app_id":             "3e2bdf9d5069fe3f4746c29d68785a6a",
+		"actions.0.environment":        "Shuffle",
+		"actions.0.parameters.0.value": "$exec.alert",
+		"actions.0.position.0.x":       178.5,
+		"triggers.0.trigger_type":      "WEBHOOK",
+		"triggers.0.status":            "running",
+		"triggers.0.parameters.0.name": "url",
+		"branches.0.source_id":         "9f8e7d6c-5b4a-4c3d-8e2f-1a0b9c8d7e6f",
+		"branches.0.conditions.0.condition.0.value":   "equals",
+		"branches.0.conditions.0.destination.0.value": "high",
+	})
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// requestIdHeaders are the headers which may hold the ID of a request, depending on how Shuffle is hosted
+var requestIdHeaders = []string{
+	"X-Request-Id",
+	"X-Cloud-Trace-Context",
+	"X-Amzn-Requestid",
+}
+
+// APIError is the error returned by the ShuffleClient methods when Shuffle refuses a request
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	// Reason is the message given by Shuffle, if any
+	Reason    string
+	RequestId string
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%s %s returned HTTP %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.Reason != "" {
+		message = fmt.Sprintf("%s: %s", message, e.Reason)
+	}
+	if e.RequestId != "" {
+		message = fmt.Sprintf("%s (request ID: %s)", message, e.RequestId)
+	}
+	return message
+}
+
+func newAPIError(method string, endpoint string, statusCode int, headers http.Header, body []byte) *APIError {
+	apiError := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		Endpoint:   endpoint,
+	}
+
+	var response struct {
+		Reason  string
+		Message string
+	}
+	if err := json.Unmarshal(body, &response); err == nil && (response.Reason != "" || response.Message != "") {
+		apiError.Reason = response.Reason
+		if apiError.Reason == "" {
+			apiError.Reason = response.Message
+		}
+	} else if len(body) > 0 {
+		apiError.Reason = redactBody(body)
+	}
+
+	for _, h := range requestIdHeaders {
+		if requestId := headers.Get(h); requestId != "" {
+			apiError.RequestId = requestId
+			break
+		}
+	}
+
+	return apiError
+}
+
+func newNotFoundError(method string, endpoint string, reason string) *APIError {
+	return &APIError{
+		StatusCode: http.StatusNotFound,
+		Method:     method,
+		Endpoint:   endpoint,
+		Reason:     reason,
+	}
+}
+
+// checkSuccess returns an APIError when Shuffle answered with `"success": false`,
+// which it does for some errors even with an HTTP 200
+func checkSuccess(method string, endpoint string, statusCode int, headers http.Header, body []byte) error {
+	var response struct {
+		Success *bool
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Success == nil || *response.Success {
+		return nil
+	}
+	return newAPIError(method, endpoint, statusCode, headers, body)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
+
+// IsNotFound tells whether the error is Shuffle saying the object does not exist
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsUnauthorized tells whether the error is Shuffle refusing the API token
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden tells whether the error is Shuffle refusing the action to the user
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const DefaultProfileName = "default"
+
+// Profile holds the settings of a named section of the shared config file, i.e:
+//
+//	[default]
+//	base_url = https://shuffler.io
+//	api_token = YOURTOKEN
+type Profile struct {
+	BaseUrl  string
+	APIToken string
+}
+
+// DefaultConfigFile returns the path of the shared config file, ~/.shuffle/config
+func DefaultConfigFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".shuffle", "config"), nil
+}
+
+// LoadProfile reads the profile with the given name from the shared config file
+func LoadProfile(path string, name string) (Profile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer file.Close()
+
+	var profile Profile
+	found := false
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			found = found || section == name
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return Profile{}, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+		if section != name {
+			continue
+		}
+
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		switch strings.TrimSpace(parts[0]) {
+		case "base_url":
+			profile.BaseUrl = value
+		case "api_token":
+			profile.APIToken = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Profile{}, err
+	}
+
+	if !found {
+		return Profile{}, fmt.Errorf("profile (%s) not found in %s", name, path)
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigFile = `# The Shuffle profiles
+[default]
+base_url = https://shuffler.io
+api_token = token1
+
+; A self-hosted Shuffle
+[ self-hosted ]
+base_url = "http://shuffle.internal:3001"
+api_token='token2'
+unknown_key = ignored
+
+[empty]
+`
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+
+	cases := []struct {
+		name     string
+		expected Profile
+	}{
+		{DefaultProfileName, Profile{BaseUrl: "https://shuffler.io", APIToken: "token1"}},
+		{"self-hosted", Profile{BaseUrl: "http://shuffle.internal:3001", APIToken: "token2"}},
+		{"empty", Profile{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile, err := LoadProfile(path, tc.name)
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if profile != tc.expected {
+				t.Fatalf("expected %+v, got %+v", tc.expected, profile)
+			}
+		})
+	}
+}
+
+func TestLoadProfileErrors(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+	if _, err := LoadProfile(path, "missing"); err == nil || !strings.Contains(err.Error(), "profile (missing) not found") {
+		t.Fatalf("expected an error for a missing profile, got %v", err)
+	}
+
+	// A malformed line is reported even outside of the profile, as the file is wrong
+	malformed := writeConfigFile(t, "[other]\nbase_url\n[default]\napi_token = token1\n")
+	if _, err := LoadProfile(malformed, DefaultProfileName); err == nil || !strings.Contains(err.Error(), ":2: expected key = value") {
+		t.Fatalf("expected an error for the malformed line, got %v", err)
+	}
+
+	if _, err := LoadProfile(filepath.Join(t.TempDir(), "missing"), DefaultProfileName); !os.IsNotExist(err) {
+		t.Fatalf("expected an error for a missing file, got %v", err)
+	}
+}
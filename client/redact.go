@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const redactedValue = "REDACTED"
+
+// secretKeys are the JSON keys (case insensitive) whose values are never logged.
+// "value" covers the app authentication fields and the workflow parameters.
+var secretKeys = map[string]bool{
+	"value":         true,
+	"apikey":        true,
+	"api_key":       true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"password":      true,
+	"secret":        true,
+	"private_id":    true,
+}
+
+var secretHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// redactHeaders returns a copy of the headers that is safe to log
+func redactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, h := range secretHeaders {
+		if redacted.Get(h) != "" {
+			redacted.Set(h, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// redactBody returns the body as a string that is safe to log. Bodies that are
+// not JSON can't be scrubbed, so only their size is returned.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(body, &content); err != nil {
+		return fmt.Sprintf("<%d bytes of non JSON content>", len(body))
+	}
+
+	redacted, err := json.Marshal(redactJson(content))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes of content>", len(body))
+	}
+	return string(redacted)
+}
+
+func redactJson(content interface{}) interface{} {
+	switch c := content.(type) {
+	case map[string]interface{}:
+		for key, value := range c {
+			switch {
+			case value == nil:
+			case secretKeys[strings.ToLower(key)]:
+				// Whatever its shape, as a secret may be a JSON object or list
+				c[key] = redactedValue
+			default:
+				c[key] = redactJson(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range c {
+			c[i] = redactJson(value)
+		}
+	}
+	return content
+}
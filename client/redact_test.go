@@ -0,0 +1,105 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			body:     ``,
+			expected: ``,
+		},
+		{
+			name:     "field values",
+			body:     `{"label": "auth", "fields": [{"key": "apikey", "value": "secret1"}, {"key": "url", "value": "https://example.com"}]}`,
+			expected: `{"fields":[{"key":"apikey","value":"REDACTED"},{"key":"url","value":"REDACTED"}],"label":"auth"}`,
+		},
+		{
+			name:     "nested client secret",
+			body:     `{"data": [{"app": {"authentication": {"client_secret": "secret1", "client_id": "id1"}}}]}`,
+			expected: `{"data":[{"app":{"authentication":{"client_id":"id1","client_secret":"REDACTED"}}}]}`,
+		},
+		{
+			name:     "tokens",
+			body:     `{"Token": "secret1", "access_token": "secret2", "refresh_token": "secret3", "api_key": "secret4", "apikey": "secret5", "token_uri": "https://example.com/token"}`,
+			expected: `{"Token":"REDACTED","access_token":"REDACTED","api_key":"REDACTED","apikey":"REDACTED","refresh_token":"REDACTED","token_uri":"https://example.com/token"}`,
+		},
+		{
+			name:     "passwords and private ids",
+			body:     `{"username": "user1", "password": "secret1", "private_id": "secret2", "secret": "secret3"}`,
+			expected: `{"password":"REDACTED","private_id":"REDACTED","secret":"REDACTED","username":"user1"}`,
+		},
+		{
+			name:     "secret objects and lists",
+			body:     `{"token": {"id": "secret1"}, "value": ["secret2"]}`,
+			expected: `{"token":"REDACTED","value":"REDACTED"}`,
+		},
+		{
+			name:     "null and non string secrets",
+			body:     `{"value": null, "secret": 42}`,
+			expected: `{"secret":"REDACTED","value":null}`,
+		},
+		{
+			name:     "list at the top level",
+			body:     `[{"id": "auth1", "fields": [{"key": "apikey", "value": "secret1"}]}]`,
+			expected: `[{"fields":[{"key":"apikey","value":"REDACTED"}],"id":"auth1"}]`,
+		},
+		{
+			name:     "not JSON",
+			body:     `apikey=secret1&token=secret2`,
+			expected: `<28 bytes of non JSON content>`,
+		},
+		{
+			name:     "HTML",
+			body:     `<html><body>token secret1</body></html>`,
+			expected: `<39 bytes of non JSON content>`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			redacted := redactBody([]byte(tc.body))
+			if redacted != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, redacted)
+			}
+			if strings.Contains(redacted, "secret1") {
+				t.Fatalf("a secret was kept in %s", redacted)
+			}
+		})
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret1")
+	headers.Set("Cookie", "session=secret2")
+	headers.Add("Set-Cookie", "session=secret3")
+	headers.Add("Set-Cookie", "other=secret4")
+	headers.Set("Content-Type", "application/json")
+
+	redacted := redactHeaders(headers)
+
+	for _, h := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if values := redacted.Values(h); len(values) != 1 || values[0] != redactedValue {
+			t.Errorf("expected %s to be redacted, got %v", h, values)
+		}
+	}
+	if redacted.Get("Content-Type") != "application/json" {
+		t.Errorf("expected the other headers to be kept, got %v", redacted)
+	}
+	// The headers of the request are left untouched
+	if headers.Get("Authorization") != "Bearer secret1" {
+		t.Errorf("expected the original headers to be kept, got %v", headers)
+	}
+	if missing := redactHeaders(http.Header{}); missing.Get("Authorization") != "" {
+		t.Errorf("expected no Authorization header to be added, got %v", missing)
+	}
+}
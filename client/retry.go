@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryWaitMin = 1 * time.Second
+	DefaultRetryWaitMax = 30 * time.Second
+)
+
+// isIdempotent tells whether a request with this method can safely be sent twice
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isConnectionRefused tells whether the request failed before reaching Shuffle,
+// in which case it is safe to send it again whatever its method
+func isConnectionRefused(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// shouldRetry tells whether a failed attempt is transient and can be retried.
+// Requests which are not idempotent are only retried when Shuffle did not process them.
+func shouldRetry(statusCode int, err error, idempotent bool) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return idempotent || isConnectionRefused(err)
+	}
+
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !idempotent {
+		return false
+	}
+
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads the Retry-After header, either a number of seconds or an HTTP date
+func parseRetryAfter(headers http.Header) (time.Duration, bool) {
+	value := headers.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// backoff returns the exponential wait before the given retry, with some jitter.
+// A RetryWaitMin of 0 retries right away.
+func (c *ShuffleClient) backoff(attempt int) time.Duration {
+	if c.RetryWaitMin <= 0 {
+		return 0
+	}
+
+	wait := c.RetryWaitMin << uint(attempt)
+	// The shift overflows after enough attempts
+	if wait>>uint(attempt) != c.RetryWaitMin || wait > c.RetryWaitMax {
+		wait = c.RetryWaitMax
+	}
+	if jitter := int64(wait / 10); jitter > 0 {
+		wait += time.Duration(rand.Int63n(jitter))
+	}
+	return wait
+}
+
+// checkResponse turns the responses which are not a success into an APIError
+func checkResponse(method string, url string, statusCode int, headers http.Header, body []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if statusCode < 200 || statusCode > 299 {
+		return newAPIError(method, url, statusCode, headers, body)
+	}
+	return checkSuccess(method, url, statusCode, headers, body)
+}
+
+// makeRequestWithRetries sends the request, retrying it on transient failures. The
+// Retry-After of Shuffle is honoured, but the request fails right away when it is
+// longer than RetryWaitMax, as waiting less would only get another refusal.
+func (c *ShuffleClient) makeRequestWithRetries(ctx context.Context, method string, url string, body []byte, idempotent bool) ([]byte, int, error) {
+	for attempt := 0; ; attempt++ {
+		rbody, statusCode, headers, err := c.doRequest(ctx, method, url, body)
+		if attempt >= c.MaxRetries || !shouldRetry(statusCode, err, idempotent) {
+			return rbody, statusCode, checkResponse(method, url, statusCode, headers, rbody, err)
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(headers); ok {
+			if retryAfter > c.RetryWaitMax {
+				apiError := newAPIError(method, url, statusCode, headers, rbody)
+				apiError.Reason = fmt.Sprintf("asked to retry after %s, more than the maximum backoff (%s)", retryAfter, c.RetryWaitMax)
+				return rbody, statusCode, apiError
+			}
+			wait = retryAfter
+		}
+
+		reason := fmt.Sprintf("HTTP %d", statusCode)
+		if err != nil {
+			reason = err.Error()
+		}
+		log.Printf("[WARN] %s %s failed (%s), retrying in %s (%d/%d)", method, url, reason, wait, attempt+1, c.MaxRetries)
+
+		select {
+		case <-ctx.Done():
+			return rbody, statusCode, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
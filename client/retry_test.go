@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	c := &ShuffleClient{RetryWaitMin: time.Second, RetryWaitMax: 30 * time.Second}
+
+	cases := []struct {
+		attempt int
+		min     time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{5, 30 * time.Second},
+		// The shift overflows
+		{70, 30 * time.Second},
+		{200, 30 * time.Second},
+	}
+	for _, tc := range cases {
+		wait := c.backoff(tc.attempt)
+		// The jitter adds up to 10%
+		if wait < tc.min || wait > tc.min+tc.min/10 {
+			t.Errorf("attempt %d: expected a wait between %s and %s, got %s", tc.attempt, tc.min, tc.min+tc.min/10, wait)
+		}
+	}
+}
+
+func TestBackoffWithoutMinimum(t *testing.T) {
+	c := &ShuffleClient{RetryWaitMin: 0, RetryWaitMax: 2 * time.Second}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if wait := c.backoff(attempt); wait != 0 {
+			t.Errorf("attempt %d: expected no wait with a minimum backoff of 0, got %s", attempt, wait)
+		}
+	}
+}
+
+// newRetryFakeShuffle answers GET / with the responses in turn, then with a success
+func newRetryFakeShuffle(t *testing.T, responses ...fakeResponse) (*fakeShuffle, *ShuffleClient) {
+	f, c := newFakeShuffle(t, map[string]fakeResponse{
+		"GET /":  {Body: `{"success": true}`},
+		"POST /": {Body: `{"success": true}`},
+	})
+	f.queue("GET /", responses...)
+	f.queue("POST /", responses...)
+
+	c.MaxRetries = DefaultMaxRetries
+	c.RetryWaitMin = 0
+	c.RetryWaitMax = time.Second
+	return f, c
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	f, c := newRetryFakeShuffle(t, fakeResponse{StatusCode: http.StatusBadGateway}, fakeResponse{StatusCode: http.StatusTooManyRequests})
+
+	start := time.Now()
+	if _, _, err := c.makeRequest(context.Background(), http.MethodGet, c.BaseUrl, nil); err != nil {
+		t.Fatalf("expected the request to succeed once retried, got %s", err)
+	}
+	assertCalls(t, f, "GET /", "GET /", "GET /")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected no wait with a minimum backoff of 0, waited %s", elapsed)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	failure := fakeResponse{StatusCode: http.StatusBadGateway}
+	f, c := newRetryFakeShuffle(t, failure, failure, failure)
+	c.MaxRetries = 1
+
+	_, statusCode, err := c.makeRequest(context.Background(), http.MethodGet, c.BaseUrl, nil)
+	if err == nil || statusCode != http.StatusBadGateway {
+		t.Fatalf("expected the last failure to be returned, got %d %v", statusCode, err)
+	}
+	assertCalls(t, f, "GET /", "GET /")
+}
+
+func TestNoRetryOfNonIdempotentRequests(t *testing.T) {
+	f, c := newRetryFakeShuffle(t, fakeResponse{StatusCode: http.StatusBadGateway})
+
+	if _, _, err := c.makeRequest(context.Background(), http.MethodPost, c.BaseUrl, []byte(`{}`)); err == nil {
+		t.Fatalf("expected the POST to fail without being retried")
+	}
+	assertCalls(t, f, "POST /")
+}
+
+func TestRetryAfterLongerThanMaximum(t *testing.T) {
+	f, c := newRetryFakeShuffle(t, fakeResponse{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"120"}}})
+
+	_, _, err := c.makeRequest(context.Background(), http.MethodGet, c.BaseUrl, nil)
+	if err == nil {
+		t.Fatalf("expected the request to give up when Retry-After is longer than the maximum backoff")
+	}
+	assertCalls(t, f, "GET /")
+}
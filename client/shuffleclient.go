@@ -2,89 +2,172 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"time"
 )
 
+// MaskedFieldValue is what Shuffle returns instead of the value of an app
+// authentication field, as the values are only decrypted during app execution
+const MaskedFieldValue = "Secret. Replaced during app execution!"
+
 type ShuffleClient struct {
+	BaseUrl  string
 	Url      string
 	APIToken string
+	// User is the authenticated user, set once the credentials are validated
+	User *User
+	// HTTPClient is shared by every request so the connections and the TLS settings are reused
+	HTTPClient *http.Client
+	transport  *http.Transport
+	// MaxRetries is the number of times a request failing with a transient error is retried,
+	// waiting between RetryWaitMin and RetryWaitMax (exponential backoff)
+	MaxRetries   int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
 }
 
 func NewShuffleClient(baseUrl string, apiToken string) (*ShuffleClient, error) {
-	var url string
 	apiPath := "api/v1/apps/authentication"
-	baseUrl = strings.TrimSuffix(baseUrl, "/")
 
-	if strings.HasPrefix(baseUrl, "https://") {
-		url = fmt.Sprintf("%s/%s", baseUrl, apiPath)
-	} else {
-		url = fmt.Sprintf("https://%s/%s", baseUrl, apiPath)
+	baseUrl, err := parseBaseUrl(baseUrl)
+	if err != nil {
+		return nil, err
 	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
 	return &ShuffleClient{
-		Url:      url,
-		APIToken: apiToken,
+		BaseUrl:      baseUrl,
+		Url:          fmt.Sprintf("%s/%s", baseUrl, apiPath),
+		APIToken:     apiToken,
+		HTTPClient:   &http.Client{Transport: transport},
+		transport:    transport,
+		MaxRetries:   DefaultMaxRetries,
+		RetryWaitMin: DefaultRetryWaitMin,
+		RetryWaitMax: DefaultRetryWaitMax,
 	}, nil
 }
 
-func (c *ShuffleClient) CreateOrUpdateAppAuth(app App) (string, error) {
-	// marshal User to json
+// parseBaseUrl validates the base URL and normalizes it to scheme://host[:port][/path],
+// https being used when no scheme is given. The path allows Shuffle to be served
+// behind a reverse proxy, i.e https://proxy.internal/shuffle
+func parseBaseUrl(baseUrl string) (string, error) {
+	rawUrl := strings.TrimSpace(baseUrl)
+	if !strings.Contains(rawUrl, "://") {
+		rawUrl = fmt.Sprintf("https://%s", rawUrl)
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf("Invalid Shuffle base URL (%s): %s", baseUrl, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("Invalid Shuffle base URL (%s): the scheme must be http or https, got %s", baseUrl, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("Invalid Shuffle base URL (%s): missing host", baseUrl)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("Invalid Shuffle base URL (%s): credentials, query and fragment are not supported", u.Redacted())
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	return u.String(), nil
+}
+
+// GetCurrentUser returns the user (and its active org) the API token belongs to
+func (c *ShuffleClient) GetCurrentUser(ctx context.Context) (User, error) {
+	url := fmt.Sprintf("%s/api/v1/getinfo", c.BaseUrl)
+	body, statusCode, err := c.makeRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		var apiError *APIError
+		if !errors.As(err, &apiError) {
+			return User{}, fmt.Errorf("Failed to reach Shuffle at %s: %s", url, err)
+		}
+		return User{}, err
+	}
+
+	var user User
+	if err := json.Unmarshal(body, &user); err != nil || !user.Success {
+		log.Printf("[WARN] Failed to unmarshal user info: %s", redactBody(body))
+		apiError := newAPIError(http.MethodGet, url, statusCode, nil, body)
+		apiError.Reason = "no user returned"
+		return User{}, apiError
+	}
+
+	return user, nil
+}
+
+// appAuthUrl returns the url of the app authentication with the given id, or of the
+// collection when the id is empty
+func (c *ShuffleClient) appAuthUrl(id string) string {
+	if id == "" {
+		return c.Url
+	}
+	return fmt.Sprintf("%s/%s", c.Url, id)
+}
+
+// CreateOrUpdateAppAuth sends the app authentication with a PUT on the collection, which
+// is how Shuffle writes them: it is updated when app.Id is set and created otherwise
+func (c *ShuffleClient) CreateOrUpdateAppAuth(ctx context.Context, app App) (string, error) {
 	jsonData, err := json.Marshal(app)
 	if err != nil {
 		return "", err
 	}
-	body, statusCode, err := c.makeRequest(http.MethodDelete, c.Url, jsonData)
+	// Without an id, the app auth is created so sending it twice would create a duplicate
+	body, statusCode, err := c.makeRequestWithRetries(ctx, http.MethodPut, c.appAuthUrl(""), jsonData, app.Id != "")
 	if err != nil {
 		return "", err
 	}
 
 	var responseJson CreateOrUpdateResponse
-	if err := json.Unmarshal([]byte(body), &responseJson); err != nil {
-		log.Printf("[WARN] Failed to unmarshal on read: %+v", body)
-		return "", err
-	}
-
-	if !responseJson.Success {
-		log.Printf("[WARN] Failed to add app auth: %s", body)
-		return "", fmt.Errorf("[WARN] Failed to add app auth: %s", body)
+	if err := json.Unmarshal([]byte(body), &responseJson); err != nil || !responseJson.Success {
+		log.Printf("[WARN] Failed to add app auth: %s", redactBody(body))
+		return "", newAPIError(http.MethodPut, c.appAuthUrl(""), statusCode, nil, body)
 	}
 
-	log.Printf("[INFO] Create or Update Response: %d %s", statusCode, string(body))
+	log.Printf("[INFO] Create or Update Response: %d %s", statusCode, responseJson.Id)
 
 	return responseJson.Id, nil
 }
 
-func (c *ShuffleClient) DeleteAppAuth(id string) error {
-	body, statusCode, err := c.makeRequest(http.MethodDelete, fmt.Sprintf("%s/%s", c.Url, id), nil)
+func (c *ShuffleClient) DeleteAppAuth(ctx context.Context, id string) error {
+	body, statusCode, err := c.makeRequest(ctx, http.MethodDelete, c.appAuthUrl(id), nil)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("[INFO] Delete Response: %d %s", statusCode, string(body))
+	log.Printf("[INFO] Delete Response: %d %s", statusCode, redactBody(body))
 
 	return nil
 }
 
-func (c *ShuffleClient) GetAllAppAuth() ([]App, error) {
-	body, _, err := c.makeRequest(http.MethodGet, c.Url, nil)
+func (c *ShuffleClient) GetAllAppAuth(ctx context.Context) ([]App, error) {
+	body, _, err := c.makeRequest(ctx, http.MethodGet, c.appAuthUrl(""), nil)
 	if err != nil {
 		return []App{}, err
 	}
 
 	var responseJson GetAppResponse
 	if err := json.Unmarshal([]byte(body), &responseJson); err != nil {
-		log.Printf("[WARN] Failed to unmarshal on read: %+v", body)
-		return []App{}, err
+		log.Printf("[WARN] Failed to unmarshal on read: %s", redactBody(body))
+		return []App{}, fmt.Errorf("Failed to read the app authentications from %s: %s", c.appAuthUrl(""), err)
 	}
 	return responseJson.Data, nil
 }
 
-func (c *ShuffleClient) GetAppAuthById(id string) (App, error) {
-	apps, err := c.GetAllAppAuth()
+func (c *ShuffleClient) GetAppAuthById(ctx context.Context, id string) (App, error) {
+	apps, err := c.GetAllAppAuth(ctx)
 
 	if err != nil {
 		return App{}, err
@@ -99,41 +182,461 @@ func (c *ShuffleClient) GetAppAuthById(id string) (App, error) {
 		return app, nil
 	}
 
-	return App{}, fmt.Errorf("App (%s) not found", id)
+	return App{}, newNotFoundError(http.MethodGet, c.appAuthUrl(""), fmt.Sprintf("App (%s) not found", id))
+}
+
+// GetAppAuthByLabel finds the app authentication with the given label. When appName
+// is empty, the label must be unique across all the apps.
+func (c *ShuffleClient) GetAppAuthByLabel(ctx context.Context, appName string, label string) (App, error) {
+	apps, err := c.GetAllAppAuth(ctx)
+
+	if err != nil {
+		return App{}, err
+	}
+
+	matches := []App{}
+	for _, app := range apps {
+		if app.Label != label || (appName != "" && app.App.Name != appName) {
+			continue
+		}
+
+		matches = append(matches, app)
+	}
+
+	if len(matches) == 0 {
+		return App{}, newNotFoundError(http.MethodGet, c.appAuthUrl(""), fmt.Sprintf("App with label (%s) not found", label))
+	}
+	if len(matches) > 1 {
+		return App{}, fmt.Errorf("%d apps found with label (%s), use the id or app_name/label instead", len(matches), label)
+	}
+
+	return matches[0], nil
+}
+
+// GetApps returns the apps of the catalog of the active org, every version of an app
+// being a different entry
+func (c *ShuffleClient) GetApps(ctx context.Context) ([]AppAuthentication, error) {
+	url := fmt.Sprintf("%s/api/v1/apps", c.BaseUrl)
+	body, _, err := c.makeRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return []AppAuthentication{}, err
+	}
+
+	var apps []AppAuthentication
+	if err := json.Unmarshal(body, &apps); err != nil {
+		log.Printf("[WARN] Failed to unmarshal apps: %s", redactBody(body))
+		return []AppAuthentication{}, fmt.Errorf("Failed to read the apps from %s: %s", url, err)
+	}
+	return apps, nil
 }
 
-func (c *ShuffleClient) makeRequest(method string, url string, body []byte) ([]byte, int, error) {
-	client := &http.Client{}
+// GetAppsByName returns the versions of the app with the given name
+func (c *ShuffleClient) GetAppsByName(ctx context.Context, name string) ([]AppAuthentication, error) {
+	apps, err := c.GetApps(ctx)
+	if err != nil {
+		return []AppAuthentication{}, err
+	}
+
+	matches := []AppAuthentication{}
+	for _, app := range apps {
+		if app.Name == name {
+			matches = append(matches, app)
+		}
+	}
+
+	if len(matches) == 0 {
+		return matches, newNotFoundError(http.MethodGet, fmt.Sprintf("%s/api/v1/apps", c.BaseUrl), fmt.Sprintf("App (%s) not found", name))
+	}
+	return matches, nil
+}
+
+// GetAppByName finds the app with the given name in the catalog. The version and the
+// id are optional, but must be given when they are needed to tell the apps apart.
+func (c *ShuffleClient) GetAppByName(ctx context.Context, name string, version string, id string) (AppAuthentication, error) {
+	apps, err := c.GetAppsByName(ctx, name)
+	if err != nil {
+		return AppAuthentication{}, err
+	}
+
+	matches := filterApps(apps, version, id, false)
+	if len(matches) == 0 && version != "" {
+		// The other versions of an app are only listed in Versions, their image and
+		// parameters are unknown so the catalog entry of the version is preferred
+		matches = filterApps(apps, version, id, true)
+	}
+
+	if len(matches) == 0 && version != "" {
+		return AppAuthentication{}, newNotFoundError(http.MethodGet, fmt.Sprintf("%s/api/v1/apps", c.BaseUrl), fmt.Sprintf("App (%s) has no version (%s), the available versions are: %s", name, version, strings.Join(appVersions(apps), ", ")))
+	}
+	if len(matches) == 0 {
+		return AppAuthentication{}, newNotFoundError(http.MethodGet, fmt.Sprintf("%s/api/v1/apps", c.BaseUrl), fmt.Sprintf("App (%s) not found with version (%s) and id (%s)", name, version, id))
+	}
+	if len(matches) > 1 {
+		ids := make([]string, 0, len(matches))
+		for _, app := range matches {
+			ids = append(ids, fmt.Sprintf("%s (version %s)", app.Id, app.AppVersion))
+		}
+		return AppAuthentication{}, fmt.Errorf("%d apps found with name (%s): %s, set the version or the id of the app to choose one", len(matches), name, strings.Join(ids, ", "))
+	}
+
+	return matches[0], nil
+}
+
+// filterApps returns the apps of the catalog with the given version and id, the
+// versions listed in Versions being only looked at when fromVersions is set
+func filterApps(apps []AppAuthentication, version string, id string, fromVersions bool) []AppAuthentication {
+	matches := []AppAuthentication{}
+	seen := make(map[string]bool)
+	for _, app := range apps {
+		if version != "" && app.AppVersion != version {
+			if !fromVersions {
+				continue
+			}
+			v, ok := findAppVersion(app, version)
+			if !ok {
+				continue
+			}
+			app.Id = v.Id
+			app.AppVersion = v.Version
+		}
+		if (id != "" && app.Id != id) || seen[app.Id] {
+			continue
+		}
+
+		seen[app.Id] = true
+		matches = append(matches, app)
+	}
+	return matches
+}
+
+func findAppVersion(app AppAuthentication, version string) (Version, bool) {
+	for _, v := range app.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// appVersions returns the versions available for the apps, sorted
+func appVersions(apps []AppAuthentication) []string {
+	seen := make(map[string]bool)
+	versions := []string{}
+	for _, app := range apps {
+		candidates := []string{app.AppVersion}
+		for _, v := range app.Versions {
+			candidates = append(candidates, v.Version)
+		}
+		for _, v := range candidates {
+			if v != "" && !seen[v] {
+				seen[v] = true
+				versions = append(versions, v)
+			}
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func (c *ShuffleClient) workflowUrl(id string) string {
+	if id == "" {
+		return fmt.Sprintf("%s/api/v1/workflows", c.BaseUrl)
+	}
+	return fmt.Sprintf("%s/api/v1/workflows/%s", c.BaseUrl, id)
+}
+
+func (c *ShuffleClient) CreateWorkflow(ctx context.Context, workflow Workflow) (string, error) {
+	// Shuffle only creates an empty workflow, the graph is saved afterward
+	jsonData, err := json.Marshal(Workflow{
+		Name:        workflow.Name,
+		Description: workflow.Description,
+		Tags:        workflow.Tags,
+	})
+	if err != nil {
+		return "", err
+	}
+	body, statusCode, err := c.makeRequest(ctx, http.MethodPost, c.workflowUrl(""), jsonData)
+	if err != nil {
+		return "", err
+	}
+
+	var created Workflow
+	if err := json.Unmarshal(body, &created); err != nil || created.Id == "" {
+		log.Printf("[WARN] Failed to create workflow: %d %s", statusCode, redactBody(body))
+		return "", newAPIError(http.MethodPost, c.workflowUrl(""), statusCode, nil, body)
+	}
+
+	log.Printf("[INFO] Create Workflow Response: %d %s", statusCode, created.Id)
+
+	workflow.Id = created.Id
+	if err := c.UpdateWorkflow(ctx, workflow); err != nil {
+		return created.Id, err
+	}
+
+	return created.Id, nil
+}
+
+func (c *ShuffleClient) GetAllWorkflows(ctx context.Context) ([]Workflow, error) {
+	body, _, err := c.makeRequest(ctx, http.MethodGet, c.workflowUrl(""), nil)
+	if err != nil {
+		return []Workflow{}, err
+	}
+
+	var workflows []Workflow
+	if err := json.Unmarshal(body, &workflows); err != nil {
+		log.Printf("[WARN] Failed to unmarshal workflows: %s", redactBody(body))
+		return []Workflow{}, fmt.Errorf("Failed to read the workflows from %s: %s", c.workflowUrl(""), err)
+	}
+	return workflows, nil
+}
+
+func (c *ShuffleClient) GetWorkflow(ctx context.Context, id string) (Workflow, error) {
+	body, statusCode, err := c.makeRequest(ctx, http.MethodGet, c.workflowUrl(id), nil)
+	if err != nil {
+		// Shuffle may answer a 400 instead of a 404 for a missing workflow, so make sure it is gone from the list
+		if IsNotFound(err) || hasStatusCode(err, http.StatusBadRequest) {
+			if exists, listErr := c.workflowExists(ctx, id); listErr == nil && !exists {
+				return Workflow{}, newNotFoundError(http.MethodGet, c.workflowUrl(id), fmt.Sprintf("Workflow (%s) not found", id))
+			}
+		}
+		return Workflow{}, err
+	}
+
+	var workflow Workflow
+	if err := json.Unmarshal(body, &workflow); err != nil {
+		log.Printf("[WARN] Failed to unmarshal workflow (%s): %d %s", id, statusCode, redactBody(body))
+		return Workflow{}, fmt.Errorf("Failed to read workflow (%s) from %s: %s", id, c.workflowUrl(id), err)
+	}
+	if workflow.Id != id {
+		return Workflow{}, fmt.Errorf("Failed to read workflow (%s) from %s: got workflow (%s) instead", id, c.workflowUrl(id), workflow.Id)
+	}
+
+	return workflow, nil
+}
+
+func (c *ShuffleClient) workflowExists(ctx context.Context, id string) (bool, error) {
+	workflows, err := c.GetAllWorkflows(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, workflow := range workflows {
+		if workflow.Id == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// workflowNodeKeys are the lists of the workflow whose entries are merged with the
+// stored ones of the same ID, and the lists of these entries merged by name
+var workflowNodeKeys = map[string][]string{
+	"actions":  {"parameters"},
+	"triggers": {"parameters"},
+	"branches": {},
+}
+
+// UpdateWorkflow saves the workflow on top of the one stored in Shuffle, so the
+// attributes Shuffle manages itself (owner, execution settings, ...) are kept, as
+// are the ones of the nodes (image, validation errors, ...).
+func (c *ShuffleClient) UpdateWorkflow(ctx context.Context, workflow Workflow) error {
+	current, err := c.getRawWorkflow(ctx, workflow.Id)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(workflow)
+	if err != nil {
+		return err
+	}
+	var managed map[string]interface{}
+	if err := json.Unmarshal(jsonData, &managed); err != nil {
+		return err
+	}
+	for key, value := range managed {
+		if parameterKeys, ok := workflowNodeKeys[key]; ok {
+			value = mergeWorkflowEntries(current[key], value, "id", parameterKeys)
+		}
+		current[key] = value
+	}
+
+	return c.putRawWorkflow(ctx, workflow.Id, current)
+}
+
+// mergeWorkflowEntries returns the managed entries, each set on top of the current
+// entry with the same value for idKey. The lists of the entries named in listKeys are
+// merged the same way, by name.
+func mergeWorkflowEntries(current interface{}, managed interface{}, idKey string, listKeys []string) interface{} {
+	currentEntries, _ := current.([]interface{})
+	managedEntries, ok := managed.([]interface{})
+	if !ok {
+		return managed
+	}
+
+	currentById := make(map[string]map[string]interface{}, len(currentEntries))
+	for _, entry := range currentEntries {
+		e, _ := entry.(map[string]interface{})
+		if id, ok := e[idKey].(string); ok && id != "" {
+			currentById[id] = e
+		}
+	}
+
+	merged := make([]interface{}, 0, len(managedEntries))
+	for _, entry := range managedEntries {
+		e, _ := entry.(map[string]interface{})
+		id, _ := e[idKey].(string)
+		currentEntry, found := currentById[id]
+		if id == "" || !found {
+			merged = append(merged, entry)
+			continue
+		}
+		result := make(map[string]interface{}, len(currentEntry)+len(e))
+		for key, value := range currentEntry {
+			result[key] = value
+		}
+		for key, value := range e {
+			for _, listKey := range listKeys {
+				if key == listKey {
+					value = mergeWorkflowEntries(currentEntry[key], value, "name", nil)
+				}
+			}
+			result[key] = value
+		}
+		merged = append(merged, result)
+	}
+	return merged
+}
+
+// getRawWorkflow returns the workflow as sent by Shuffle, so the attributes the
+// provider doesn't manage are kept on update
+func (c *ShuffleClient) getRawWorkflow(ctx context.Context, id string) (map[string]interface{}, error) {
+	body, statusCode, err := c.makeRequest(ctx, http.MethodGet, c.workflowUrl(id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var current map[string]interface{}
+	if err := json.Unmarshal(body, &current); err != nil {
+		log.Printf("[WARN] Failed to unmarshal workflow (%s): %d %s", id, statusCode, redactBody(body))
+		return nil, fmt.Errorf("Failed to read workflow (%s) before update: %s", id, err)
+	}
+	return current, nil
+}
+
+func (c *ShuffleClient) putRawWorkflow(ctx context.Context, id string, workflow map[string]interface{}) error {
+	jsonData, err := json.Marshal(workflow)
+	if err != nil {
+		return err
+	}
+	body, statusCode, err := c.makeRequest(ctx, http.MethodPut, c.workflowUrl(id), jsonData)
+	if err != nil {
+		return err
+	}
+
+	var responseJson WorkflowResponse
+	if err := json.Unmarshal(body, &responseJson); err != nil || !responseJson.Success {
+		log.Printf("[WARN] Failed to update workflow (%s): %d %s", id, statusCode, redactBody(body))
+		return newAPIError(http.MethodPut, c.workflowUrl(id), statusCode, nil, body)
+	}
+
+	log.Printf("[INFO] Update Workflow Response: %d %s", statusCode, redactBody(body))
+
+	return nil
+}
+
+// DetachAppAuthFromWorkflows removes the app authentication from the actions of the
+// workflows using it, so Shuffle accepts to delete it
+func (c *ShuffleClient) DetachAppAuthFromWorkflows(ctx context.Context, app App) error {
+	for _, usage := range app.Usage {
+		workflow, err := c.getRawWorkflow(ctx, usage.WorkfflowId)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		actions, _ := workflow["actions"].([]interface{})
+		detached := false
+		for _, a := range actions {
+			action, ok := a.(map[string]interface{})
+			if ok && action["authentication_id"] == app.Id {
+				action["authentication_id"] = ""
+				detached = true
+			}
+		}
+		if !detached {
+			continue
+		}
+
+		log.Printf("[INFO] Detaching app auth (%s) from workflow (%s)", app.Id, usage.WorkfflowId)
+		if err := c.putRawWorkflow(ctx, usage.WorkfflowId, workflow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ShuffleClient) DeleteWorkflow(ctx context.Context, id string) error {
+	body, statusCode, err := c.makeRequest(ctx, http.MethodDelete, c.workflowUrl(id), nil)
+	if err != nil {
+		return err
+	}
+
+	var responseJson WorkflowResponse
+	if err := json.Unmarshal(body, &responseJson); err != nil || !responseJson.Success {
+		log.Printf("[WARN] Failed to delete workflow (%s): %d %s", id, statusCode, redactBody(body))
+		return newAPIError(http.MethodDelete, c.workflowUrl(id), statusCode, nil, body)
+	}
+
+	log.Printf("[INFO] Delete Workflow Response: %d %s", statusCode, redactBody(body))
+
+	return nil
+}
+
+// makeRequest sends the request with the given method, retrying it on transient
+// failures when the method is idempotent
+func (c *ShuffleClient) makeRequest(ctx context.Context, method string, url string, body []byte) ([]byte, int, error) {
+	return c.makeRequestWithRetries(ctx, method, url, body, isIdempotent(method))
+}
+
+// doRequest makes a single attempt of the request
+func (c *ShuffleClient) doRequest(ctx context.Context, method string, url string, body []byte) ([]byte, int, http.Header, error) {
 	var req *http.Request
 	var err error
 
 	if body == nil {
-		req, err = http.NewRequest(method, url, nil)
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 	} else {
 		// set the HTTP method, url, and request body
-		req, err = http.NewRequest(http.MethodPut, url, bytes.NewBuffer(body))
-		if err != nil {
-			defer req.Body.Close()
-		}
+		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	}
 
 	if err != nil {
-		return nil, -1, err
+		return nil, -1, nil, err
 	}
 
 	// set the request header Content-Type for json
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.APIToken)
 
-	resp, err := client.Do(req)
+	// Never log the token nor the secrets sent or received, see redact.go
+	log.Printf("[TRACE] Request: %s %s %v %s", method, url, redactHeaders(req.Header), redactBody(body))
+
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, -1, err
+		return nil, -1, nil, err
 	}
+	defer resp.Body.Close()
 
 	rbody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, -1, err
+		return nil, -1, nil, err
 	}
 
-	return rbody, resp.StatusCode, nil
+	log.Printf("[TRACE] Response: %s %s %d %v %s", method, url, resp.StatusCode, redactHeaders(resp.Header), redactBody(rbody))
+
+	return rbody, resp.StatusCode, resp.Header, nil
 }
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// TLSOptions configures how the client connects to a self-hosted Shuffle
+type TLSOptions struct {
+	// CACertFile and CACertPEM are added to the system's trusted CAs
+	CACertFile string
+	CACertPEM  string
+	// ClientCert and ClientKey are either PEM contents or paths to PEM files
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+}
+
+func (o TLSOptions) isEmpty() bool {
+	return o == TLSOptions{}
+}
+
+// readPEM returns the value if it is PEM content, or the content of the file it points to
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return ioutil.ReadFile(value)
+}
+
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.InsecureSkipVerify {
+		log.Printf("[WARN] TLS certificate verification is disabled for Shuffle")
+	}
+
+	if o.CACertFile != "" || o.CACertPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if o.CACertFile != "" {
+			caCert, err := ioutil.ReadFile(o.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read the CA certificate file: %s", err)
+			}
+			if !pool.AppendCertsFromPEM(caCert) {
+				return nil, fmt.Errorf("No certificate found in the CA certificate file (%s)", o.CACertFile)
+			}
+		}
+		if o.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(o.CACertPEM)) {
+			return nil, fmt.Errorf("No certificate found in the CA certificate PEM")
+		}
+
+		config.RootCAs = pool
+	}
+
+	if o.ClientCert != "" || o.ClientKey != "" {
+		if o.ClientCert == "" || o.ClientKey == "" {
+			return nil, fmt.Errorf("Both the client certificate and the client key must be set")
+		}
+
+		certPEM, err := readPEM(o.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the client certificate: %s", err)
+		}
+		keyPEM, err := readPEM(o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the client key: %s", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid client certificate or key: %s", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// ConfigureTLS applies the options to the transport shared by every request of the client
+func (c *ShuffleClient) ConfigureTLS(options TLSOptions) error {
+	if options.isEmpty() {
+		return nil
+	}
+
+	config, err := options.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	c.transport.TLSClientConfig = config
+
+	return nil
+}
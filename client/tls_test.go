@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTLSFakeShuffle starts a fake Shuffle over TLS, requiring a client certificate
+// signed by clientCA when it is set. It returns the PEM of the server certificate.
+func newTLSFakeShuffle(t *testing.T, clientCA *x509.Certificate) (string, string) {
+	f := &fakeShuffle{t: t, routes: map[string]fakeResponse{
+		"GET /api/v1/getinfo": {Body: `{"success": true, "username": "admin"}`},
+	}}
+	server := httptest.NewUnstartedServer(f)
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCA)
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server.URL, string(serverCert)
+}
+
+// newClientCertificate returns a self-signed client certificate and its key, as PEM
+func newClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating the key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating the certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing the certificate: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling the key: %s", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, string(certPEM), string(keyPEM)
+}
+
+func writeTempFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+	return path
+}
+
+func connect(t *testing.T, baseUrl string, options TLSOptions) error {
+	t.Helper()
+	c, err := NewShuffleClient(baseUrl, "test-token")
+	if err != nil {
+		t.Fatalf("NewShuffleClient: %s", err)
+	}
+	c.MaxRetries = 0
+	if err := c.ConfigureTLS(options); err != nil {
+		return err
+	}
+	_, err = c.GetCurrentUser(context.Background())
+	return err
+}
+
+func TestTLSServerTrust(t *testing.T) {
+	baseUrl, serverCert := newTLSFakeShuffle(t, nil)
+
+	cases := []struct {
+		name    string
+		options TLSOptions
+		err     string
+	}{
+		{name: "untrusted", options: TLSOptions{}, err: "certificate"},
+		{name: "CA file", options: TLSOptions{CACertFile: writeTempFile(t, "ca.pem", serverCert)}},
+		{name: "CA PEM", options: TLSOptions{CACertPEM: serverCert}},
+		{name: "insecure", options: TLSOptions{InsecureSkipVerify: true}},
+		{name: "CA file without certificate", options: TLSOptions{CACertFile: writeTempFile(t, "empty.pem", "not a certificate")}, err: "No certificate found in the CA certificate file"},
+		{name: "missing CA file", options: TLSOptions{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}, err: "Failed to read the CA certificate file"},
+		{name: "CA PEM without certificate", options: TLSOptions{CACertPEM: "not a certificate"}, err: "No certificate found in the CA certificate PEM"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := connect(t, baseUrl, tc.options)
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("expected the error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	clientCert, certPEM, keyPEM := newClientCertificate(t)
+	baseUrl, serverCert := newTLSFakeShuffle(t, clientCert)
+
+	cases := []struct {
+		name       string
+		clientCert string
+		clientKey  string
+		err        string
+	}{
+		{name: "PEM", clientCert: certPEM, clientKey: keyPEM},
+		{name: "files", clientCert: writeTempFile(t, "cert.pem", certPEM), clientKey: writeTempFile(t, "key.pem", keyPEM)},
+		{name: "no certificate", err: "certificate"},
+		{name: "certificate only", clientCert: certPEM, err: "Both the client certificate and the client key must be set"},
+		{name: "key only", clientKey: keyPEM, err: "Both the client certificate and the client key must be set"},
+		{name: "missing key file", clientCert: certPEM, clientKey: filepath.Join(t.TempDir(), "missing.pem"), err: "Failed to read the client key"},
+		{name: "mismatched key", clientCert: certPEM, clientKey: certPEM, err: "Invalid client certificate or key"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := connect(t, baseUrl, TLSOptions{CACertPEM: serverCert, ClientCert: tc.clientCert, ClientKey: tc.clientKey})
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("expected the error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package data_sources
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log"
-	"strconv"
-	"time"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
 	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
 )
@@ -20,44 +25,219 @@ func DataSourceAllAppAuthentication() *schema.Resource {
 				Type: schema.TypeList,
 				Elem: client.GetDefaultAppSchema(),
 			},
+			"ids": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The IDs of the app authentications found",
+			},
+			"labels": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The labels of the app authentications found",
+			},
 		},
 	}
 
 	r = utils.RecurseSetSchemaStatus(r, utils.Computed, true)
 
+	r.Schema["app_name"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Only keep the authentications of the app with this name",
+	}
+	r.Schema["label_regex"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validation.StringIsValidRegExp,
+		Description:  "Only keep the authentications with a label matching this regular expression",
+	}
+	r.Schema["active"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Description: "Only keep the authentications which are active, or inactive",
+	}
+	r.Schema["encrypted"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Description: "Only keep the authentications which are encrypted, or not",
+	}
+	r.Schema["org_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Only keep the authentications of this org",
+	}
+	r.Schema["used_by_workflow"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Only keep the authentications used by the workflow with this ID",
+	}
+
 	return r
 }
 
+// appAuthFilter holds the filters of the data source, the unset ones being nil
+type appAuthFilter struct {
+	appName        *string
+	labelRegex     *regexp.Regexp
+	active         *bool
+	encrypted      *bool
+	orgId          *string
+	usedByWorkflow *string
+}
+
+func getAppAuthFilter(d *schema.ResourceData) (appAuthFilter, error) {
+	var filter appAuthFilter
+
+	config := d.GetRawConfig()
+	isSet := func(name string) bool {
+		return !config.IsNull() && !config.GetAttr(name).IsNull()
+	}
+
+	if isSet("app_name") {
+		v := d.Get("app_name").(string)
+		filter.appName = &v
+	}
+	if isSet("label_regex") {
+		re, err := regexp.Compile(d.Get("label_regex").(string))
+		if err != nil {
+			return filter, err
+		}
+		filter.labelRegex = re
+	}
+	if isSet("active") {
+		v := d.Get("active").(bool)
+		filter.active = &v
+	}
+	if isSet("encrypted") {
+		v := d.Get("encrypted").(bool)
+		filter.encrypted = &v
+	}
+	if isSet("org_id") {
+		v := d.Get("org_id").(string)
+		filter.orgId = &v
+	}
+	if isSet("used_by_workflow") {
+		v := d.Get("used_by_workflow").(string)
+		filter.usedByWorkflow = &v
+	}
+
+	return filter, nil
+}
+
+// hash returns an ID which only changes with the filters or the authentications found
+func (f appAuthFilter) hash(ids []string) string {
+	sortedIds := append([]string{}, ids...)
+	sort.Strings(sortedIds)
+
+	parts := []string{
+		fmt.Sprintf("app_name=%v", stringOrNil(f.appName)),
+		fmt.Sprintf("label_regex=%v", f.labelRegex),
+		fmt.Sprintf("active=%v", boolOrNil(f.active)),
+		fmt.Sprintf("encrypted=%v", boolOrNil(f.encrypted)),
+		fmt.Sprintf("org_id=%v", stringOrNil(f.orgId)),
+		fmt.Sprintf("used_by_workflow=%v", stringOrNil(f.usedByWorkflow)),
+		fmt.Sprintf("ids=%s", strings.Join(sortedIds, ",")),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func stringOrNil(v *string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func boolOrNil(v *bool) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func (f appAuthFilter) match(app client.App) bool {
+	if f.appName != nil && app.App.Name != *f.appName {
+		return false
+	}
+	if f.labelRegex != nil && !f.labelRegex.MatchString(app.Label) {
+		return false
+	}
+	if f.active != nil && app.Active != *f.active {
+		return false
+	}
+	if f.encrypted != nil && app.Encrypted != *f.encrypted {
+		return false
+	}
+	if f.orgId != nil && app.OrgId != *f.orgId {
+		return false
+	}
+	if f.usedByWorkflow != nil {
+		used := false
+		for _, usage := range app.Usage {
+			used = used || usage.WorkfflowId == *f.usedByWorkflow
+		}
+		if !used {
+			return false
+		}
+	}
+	return true
+}
+
 func dataSourceAllAppAuthenticationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	c := m.(*client.ShuffleClient)
 
-	allAppAuth, err := c.GetAllAppAuth()
+	filter, err := getAppAuthFilter(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	allAppAuth, err := c.GetAllAppAuth(ctx)
 
 	if err != nil {
 		d.SetId("")
 		return diag.FromErr(err)
 	}
 
-	allAppAuthMap := make([]interface{}, len(allAppAuth))
+	allAppAuthMap := make([]interface{}, 0, len(allAppAuth))
+	ids := make([]string, 0, len(allAppAuth))
+	labels := make([]string, 0, len(allAppAuth))
 
-	for i, app := range allAppAuth {
-		appTemp := utils.GenerateMapFromFields(app)
-		allAppAuthMap[i] = appTemp
+	for _, app := range allAppAuth {
+		if !filter.match(app) {
+			continue
+		}
+		appTemp := utils.Flatten(maskSecrets(app))
+		allAppAuthMap = append(allAppAuthMap, appTemp)
+		ids = append(ids, app.Id)
+		labels = append(labels, app.Label)
 	}
-	// appTemp := utils.GenerateMapFromFields(allAppAuth[0])
-	// allAppAuthMap[0] = appTemp
-
 	// log.Printf("[DEBUG] Got allAppAuthMap: %+v ", allAppAuthMap)
 
 	if err := d.Set("all_app_auths", allAppAuthMap); err != nil {
-		log.Printf("[ERROR] Got error (%+v) setting with allAppAuthMap: %+v ", err, allAppAuthMap)
+		log.Printf("[ERROR] Got error (%+v) setting all_app_auths", err)
 		return diag.FromErr(err)
 	}
+	d.Set("ids", ids)
+	d.Set("labels", labels)
 
-	// always run
-	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+	d.SetId(filter.hash(ids))
 
 	return diags
 }
+
+// maskSecrets replaces the secrets of the app. Sensitive only hides the values from the
+// plan and output, they would still be written in plain text to the state, and the
+// data sources have no use for them, so make sure no secret ends up in the state
+func maskSecrets(app client.App) client.App {
+	for j := range app.Fields {
+		app.Fields[j].Value = client.MaskedFieldValue
+	}
+	if app.App.Authentication.ClientSecret != "" {
+		app.App.Authentication.ClientSecret = client.MaskedFieldValue
+	}
+	return app
+}
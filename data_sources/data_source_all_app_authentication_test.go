@@ -0,0 +1,150 @@
+package data_sources
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+var testFilterAttributes = map[string]cty.Type{
+	"app_name":         cty.String,
+	"label_regex":      cty.String,
+	"active":           cty.Bool,
+	"encrypted":        cty.Bool,
+	"org_id":           cty.String,
+	"used_by_workflow": cty.String,
+}
+
+// testFilterData returns the data of the data source with the given filters configured,
+// the raw config telling the filters set to their zero value from the unset ones
+func testFilterData(config map[string]interface{}) *schema.ResourceData {
+	attributes := map[string]string{}
+	raw := map[string]cty.Value{}
+	for name, typ := range testFilterAttributes {
+		raw[name] = cty.NullVal(typ)
+	}
+	for name, value := range config {
+		attributes[name] = fmt.Sprintf("%v", value)
+		switch v := value.(type) {
+		case bool:
+			raw[name] = cty.BoolVal(v)
+		case string:
+			raw[name] = cty.StringVal(v)
+		}
+	}
+	return DataSourceAllAppAuthentication().Data(&terraform.InstanceState{
+		Attributes: attributes,
+		RawConfig:  cty.ObjectVal(raw),
+	})
+}
+
+func TestGetAppAuthFilter(t *testing.T) {
+	filter, err := getAppAuthFilter(testFilterData(map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if filter != (appAuthFilter{}) {
+		t.Fatalf("expected no filter, got %+v", filter)
+	}
+
+	filter, err = getAppAuthFilter(testFilterData(map[string]interface{}{
+		"app_name":         "",
+		"label_regex":      "^prod",
+		"active":           false,
+		"encrypted":        false,
+		"org_id":           "org1",
+		"used_by_workflow": "workflow1",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if filter.appName == nil || *filter.appName != "" {
+		t.Fatalf("expected an empty app_name to be a filter, got %v", filter.appName)
+	}
+	if filter.labelRegex == nil || filter.labelRegex.String() != "^prod" {
+		t.Fatalf("expected the label_regex filter, got %v", filter.labelRegex)
+	}
+	if filter.active == nil || *filter.active || filter.encrypted == nil || *filter.encrypted {
+		t.Fatalf("expected false to be a filter, got %v and %v", filter.active, filter.encrypted)
+	}
+	if filter.orgId == nil || *filter.orgId != "org1" || filter.usedByWorkflow == nil || *filter.usedByWorkflow != "workflow1" {
+		t.Fatalf("expected the org_id and used_by_workflow filters, got %v and %v", filter.orgId, filter.usedByWorkflow)
+	}
+
+	if _, err := getAppAuthFilter(testFilterData(map[string]interface{}{"label_regex": "("})); err == nil {
+		t.Fatalf("expected an error for an invalid regular expression")
+	}
+}
+
+func TestAppAuthFilterMatch(t *testing.T) {
+	cases := []struct {
+		name   string
+		config map[string]interface{}
+		ids    []string
+	}{
+		{name: "no filter", config: map[string]interface{}{}, ids: []string{"auth1", "auth2", "auth3"}},
+		{name: "app name", config: map[string]interface{}{"app_name": "http"}, ids: []string{"auth1", "auth3"}},
+		{name: "label regex", config: map[string]interface{}{"label_regex": "^stag"}, ids: []string{"auth3"}},
+		{name: "active", config: map[string]interface{}{"active": true}, ids: []string{"auth1", "auth3"}},
+		{name: "inactive", config: map[string]interface{}{"active": false}, ids: []string{"auth2"}},
+		{name: "encrypted", config: map[string]interface{}{"encrypted": true}, ids: []string{"auth2"}},
+		{name: "not encrypted", config: map[string]interface{}{"encrypted": false}, ids: []string{"auth1", "auth3"}},
+		{name: "org", config: map[string]interface{}{"org_id": "org2"}, ids: []string{"auth3"}},
+		{name: "used by workflow", config: map[string]interface{}{"used_by_workflow": "workflow1"}, ids: []string{"auth1"}},
+		{name: "used by unknown workflow", config: map[string]interface{}{"used_by_workflow": "workflow3"}, ids: []string{}},
+		{name: "combined", config: map[string]interface{}{"app_name": "http", "label_regex": "prod", "active": true}, ids: []string{"auth1"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := getAppAuthFilter(testFilterData(tc.config))
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			ids := []string{}
+			for _, app := range testAuths() {
+				if filter.match(app) {
+					ids = append(ids, app.Id)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.ids) {
+				t.Fatalf("expected the authentications %v, got %v", tc.ids, ids)
+			}
+		})
+	}
+}
+
+func TestAppAuthFilterHash(t *testing.T) {
+	filter, err := getAppAuthFilter(testFilterData(map[string]interface{}{"app_name": "http", "active": true}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	id := filter.hash([]string{"auth1", "auth3"})
+
+	if reordered := filter.hash([]string{"auth3", "auth1"}); reordered != id {
+		t.Fatalf("expected the ID to be kept when the authentications are reordered, got %s and %s", id, reordered)
+	}
+	if changed := filter.hash([]string{"auth1"}); changed == id {
+		t.Fatalf("expected the ID to change with the authentications found")
+	}
+
+	others := []map[string]interface{}{
+		{"app_name": "jira", "active": true},
+		{"app_name": "http", "active": false},
+		{"app_name": "http"},
+		{"app_name": "http", "active": true, "encrypted": false},
+		{"app_name": "http", "active": true, "label_regex": "prod"},
+	}
+	for _, config := range others {
+		other, err := getAppAuthFilter(testFilterData(config))
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+		if other.hash([]string{"auth1", "auth3"}) == id {
+			t.Fatalf("expected the ID to change with the filters %v", config)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package data_sources
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
+)
+
+func DataSourceAppAuthentication() *schema.Resource {
+	r := &schema.Resource{
+		ReadContext: dataSourceAppAuthenticationRead,
+		Schema:      client.GetDefaultAppSchema().Schema,
+	}
+
+	r = utils.RecurseSetSchemaStatus(r, utils.Computed, true)
+
+	r.Schema["id"].Optional = true
+	r.Schema["id"].Description = "The ID of the app authentication"
+	r.Schema["id"].ExactlyOneOf = []string{"id", "label"}
+	r.Schema["label"].Optional = true
+	r.Schema["fields"].Description = "The keys of the fields of the app authentication. Their values are masked and never written to the state"
+	r.Schema["app_name"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		RequiredWith: []string{"label"},
+		Description:  "The name of the app, when the label isn't unique across the apps",
+	}
+
+	return r
+}
+
+func dataSourceAppAuthenticationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.ShuffleClient)
+
+	var app client.App
+	var err error
+	if id := d.Get("id").(string); id != "" {
+		app, err = c.GetAppAuthById(ctx, id)
+	} else {
+		app, err = c.GetAppAuthByLabel(ctx, d.Get("app_name").(string), d.Get("label").(string))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := utils.FlattenToResourceData(d, client.GetDefaultAppSchema().Schema, maskSecrets(app)); err != nil {
+		log.Printf("[ERROR] Got error (%+v) setting the app authentication", err)
+		return diag.FromErr(err)
+	}
+
+	d.SetId(app.Id)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package data_sources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+func TestDataSourceAppAuthenticationRead(t *testing.T) {
+	c := newStubShuffle(t, testAuths()...)
+
+	cases := []struct {
+		name   string
+		config map[string]interface{}
+		id     string
+		err    string
+	}{
+		{name: "id", config: map[string]interface{}{"id": "auth3"}, id: "auth3"},
+		{name: "unique label", config: map[string]interface{}{"label": "staging"}, id: "auth3"},
+		{name: "label of the app", config: map[string]interface{}{"label": "prod", "app_name": "jira"}, id: "auth2"},
+		{name: "unknown id", config: map[string]interface{}{"id": "auth4"}, err: "not found"},
+		{name: "no match", config: map[string]interface{}{"label": "dev"}, err: "App with label (dev) not found"},
+		{name: "no match for the app", config: map[string]interface{}{"label": "staging", "app_name": "jira"}, err: "App with label (staging) not found"},
+		{name: "several matches", config: map[string]interface{}{"label": "prod"}, err: "2 apps found with label (prod), use the id or app_name/label instead"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := DataSourceAppAuthentication()
+			d := schema.TestResourceDataRaw(t, r.Schema, tc.config)
+			diags := r.ReadContext(context.Background(), d, c)
+
+			if tc.err != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.err) {
+					t.Fatalf("expected the error %q, got %+v", tc.err, diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error %+v", diags)
+			}
+			if d.Id() != tc.id {
+				t.Fatalf("expected the authentication %s, got %s", tc.id, d.Id())
+			}
+		})
+	}
+}
+
+func TestDataSourceAppAuthenticationMasksSecrets(t *testing.T) {
+	c := newStubShuffle(t, testAuths()...)
+
+	r := DataSourceAppAuthentication()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{"id": "auth1"})
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("unexpected error %+v", diags)
+	}
+	if value := d.Get("fields.0.value"); value != client.MaskedFieldValue {
+		t.Fatalf("expected the value of the field to be masked, got %v", value)
+	}
+}
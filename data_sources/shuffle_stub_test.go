@@ -0,0 +1,65 @@
+package data_sources
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+// stubShuffle is a Shuffle serving the given app authentications
+type stubShuffle struct {
+	auths []client.App
+}
+
+func newStubShuffle(t *testing.T, auths ...client.App) *client.ShuffleClient {
+	server := httptest.NewServer(&stubShuffle{auths: auths})
+	t.Cleanup(server.Close)
+
+	c, err := client.NewShuffleClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatalf("NewShuffleClient: %s", err)
+	}
+	c.MaxRetries = 0
+	return c
+}
+
+func (s *stubShuffle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/api/v1/apps/authentication" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(client.GetAppResponse{Data: s.auths, Success: true})
+}
+
+func testAuths() []client.App {
+	return []client.App{
+		{
+			Id:     "auth1",
+			Label:  "prod",
+			Active: true,
+			OrgId:  "org1",
+			App:    client.AppAuthentication{Name: "http", Id: "http1", AppVersion: "1.0.0"},
+			Fields: []client.Field{{Key: "apikey", Value: "secret"}},
+			Usage:  []client.AppUsage{{WorkfflowId: "workflow1", Nodes: []string{"node1"}}},
+		},
+		{
+			Id:        "auth2",
+			Label:     "prod",
+			Encrypted: true,
+			OrgId:     "org1",
+			App:       client.AppAuthentication{Name: "jira", Id: "jira1", AppVersion: "1.0.0"},
+		},
+		{
+			Id:     "auth3",
+			Label:  "staging",
+			Active: true,
+			OrgId:  "org2",
+			App:    client.AppAuthentication{Name: "http", Id: "http1", AppVersion: "1.0.0"},
+			Usage:  []client.AppUsage{{WorkfflowId: "workflow2"}},
+		},
+	}
+}
@@ -0,0 +1,11 @@
+data "shufflesoar_all_app_authentications" "all_app_authentications" {}
+
+data "shufflesoar_all_app_authentications" "active_ses" {
+  app_name    = "AWS ses"
+  label_regex = "^prod-"
+  active      = true
+}
+
+output "active_ses_ids" {
+  value = data.shufflesoar_all_app_authentications.active_ses.ids
+}
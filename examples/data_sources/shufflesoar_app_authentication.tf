@@ -1 +1,4 @@
-data "shufflesoar_all_app_authentications" "all_app_authentications" {}
+data "shufflesoar_app_authentication" "ses" {
+  app_name = "AWS ses"
+  label    = "A test app"
+}
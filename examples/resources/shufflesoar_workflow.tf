@@ -0,0 +1,39 @@
+resource "shufflesoar_workflow" "example" {
+  name        = "A test workflow"
+  description = "Repeats the webhook's body"
+  start       = "0f5b7e21-5e4d-4a3e-9a51-62e3c0f0b0a1"
+
+  triggers {
+    id           = "6b0c9f9e-1b7a-4b8e-8a7e-3e1f2c5d4a10"
+    app_name     = "Webhook"
+    name         = "Webhook"
+    label        = "Incoming alert"
+    trigger_type = "WEBHOOK"
+    environment  = "cloud"
+  }
+
+  actions {
+    id          = "0f5b7e21-5e4d-4a3e-9a51-62e3c0f0b0a1"
+    app_name    = "Shuffle Tools"
+    app_version = "1.2.0"
+    name        = "repeat_back_to_me"
+    label       = "Repeat the alert"
+    environment = "cloud"
+
+    parameters {
+      name  = "call"
+      value = "$exec"
+    }
+
+    position {
+      x = 200
+      y = 100
+    }
+  }
+
+  branches {
+    id             = "d3c1a6f2-8f7e-4c8a-b9a3-1f0e6d2c7b54"
+    source_id      = "6b0c9f9e-1b7a-4b8e-8a7e-3e1f2c5d4a10"
+    destination_id = "0f5b7e21-5e4d-4a3e-9a51-62e3c0f0b0a1"
+  }
+}
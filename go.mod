@@ -3,7 +3,8 @@ module github.com/tristandostaler/terraform-provider-shufflesoar
 go 1.16
 
 require (
-	github.com/hashicorp/hcl/v2 v2.8.2 // indirect
+	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
+	github.com/hashicorp/hcl/v2 v2.8.2
 	github.com/hashicorp/terraform-plugin-docs v0.5.1 // indirect
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.10.1
 	github.com/mattn/go-colorable v0.1.11 // indirect
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
 	"github.com/tristandostaler/terraform-provider-shufflesoar/data_sources"
@@ -31,30 +36,198 @@ func Provider() *schema.Provider {
 		Schema: map[string]*schema.Schema{
 			"shuffle_base_url": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Shuffle's base URL (i.e https://shuffler.io or https://ca.shuffler.io)",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SHUFFLE_BASE_URL", nil),
+				Description: "Shuffle's base URL (i.e https://shuffler.io, https://ca.shuffler.io or, for a self-hosted Shuffle, http://shuffle.internal:3001 or https://proxy.internal/shuffle). https is used when no scheme is given. Can also be set with the `SHUFFLE_BASE_URL` environment variable or the `base_url` of the profile.",
 			},
 			"shuffle_api_token": {
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Shuffle's API token",
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("SHUFFLE_API_TOKEN", nil),
+				Description: "Shuffle's API token. Can also be set with the `SHUFFLE_API_TOKEN` environment variable or the `api_token` of the profile.",
+			},
+			"shuffle_profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SHUFFLE_PROFILE", nil),
+				Description: "The name of the profile to read from the shared config file when the base URL or the API token are not set. Can also be set with the `SHUFFLE_PROFILE` environment variable. Defaults to `" + client.DefaultProfileName + "`.",
+			},
+			"ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The path of a PEM file with the certificate(s) of the CA(s) to trust, in addition to the system's, i.e for a self-hosted Shuffle using an internal CA.",
+			},
+			"ca_cert_pem": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The PEM content of the certificate(s) of the CA(s) to trust, in addition to the system's.",
+			},
+			"client_cert": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_key"},
+				Description:  "The client certificate to present for mutual TLS, as PEM content or the path of a PEM file.",
+			},
+			"client_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"client_cert"},
+				Description:  "The private key of the client certificate, as PEM content or the path of a PEM file.",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip the verification of Shuffle's TLS certificate. Only use this for testing.",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      client.DefaultMaxRetries,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The number of times a request failing with a transient error (connection error, HTTP 429 or 5xx) is retried. Requests which are not idempotent, like creations, are only retried when Shuffle did not process them.",
+			},
+			"min_backoff": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(client.DefaultRetryWaitMin / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The minimum time to wait before retrying a request, in seconds. The wait doubles with every retry, `0` retries right away.",
+			},
+			"max_backoff": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(client.DefaultRetryWaitMax / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum time to wait before retrying a request, in seconds. A request is not retried when Shuffle's `Retry-After` is longer than this.",
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      60,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum time a single request to Shuffle can take, in seconds. `0` means no limit, the resources' `timeouts` still apply.",
+			},
+			"skip_credentials_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip the call to Shuffle made to validate the base URL and the API token when the provider is configured.",
+			},
+			"shuffle_config_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SHUFFLE_CONFIG_FILE", nil),
+				Description: "The path of the shared config file holding the profiles. Can also be set with the `SHUFFLE_CONFIG_FILE` environment variable. Defaults to `~/.shuffle/config`.",
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"shufflesoar_app_authentication": resources.ResourceAppAuthentication(),
+			"shufflesoar_workflow":           resources.ResourceWorkflow(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"shufflesoar_all_app_authentications": data_sources.DataSourceAllAppAuthentication(),
+			"shufflesoar_app_authentication":      data_sources.DataSourceAppAuthentication(),
 		},
-		ConfigureFunc: providerConfigure,
+		ConfigureContextFunc: providerConfigure,
 	}
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	// The provider arguments (or their environment variables) take precedence over the profile
 	shuffle_base_url := d.Get("shuffle_base_url").(string)
 	shuffle_api_token := d.Get("shuffle_api_token").(string)
 
-	c, _ := client.NewShuffleClient(shuffle_base_url, shuffle_api_token)
+	if shuffle_base_url == "" || shuffle_api_token == "" {
+		profile, err := loadProfile(d)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		if shuffle_base_url == "" {
+			shuffle_base_url = profile.BaseUrl
+		}
+		if shuffle_api_token == "" {
+			shuffle_api_token = profile.APIToken
+		}
+	}
+
+	if shuffle_base_url == "" {
+		return nil, diag.Errorf("No Shuffle base URL found: set shuffle_base_url in the provider configuration, the SHUFFLE_BASE_URL environment variable or base_url in the profile")
+	}
+	if shuffle_api_token == "" {
+		return nil, diag.Errorf("No Shuffle API token found: set shuffle_api_token in the provider configuration, the SHUFFLE_API_TOKEN environment variable or api_token in the profile")
+	}
+
+	c, err := client.NewShuffleClient(shuffle_base_url, shuffle_api_token)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	c.HTTPClient.Timeout = time.Duration(d.Get("request_timeout").(int)) * time.Second
+	c.MaxRetries = d.Get("max_retries").(int)
+	c.RetryWaitMin = time.Duration(d.Get("min_backoff").(int)) * time.Second
+	c.RetryWaitMax = time.Duration(d.Get("max_backoff").(int)) * time.Second
+	if c.RetryWaitMax < c.RetryWaitMin {
+		return nil, diag.Errorf("max_backoff (%d) must be greater than or equal to min_backoff (%d)", d.Get("max_backoff").(int), d.Get("min_backoff").(int))
+	}
+
+	err = c.ConfigureTLS(client.TLSOptions{
+		CACertFile:         d.Get("ca_cert_file").(string),
+		CACertPEM:          d.Get("ca_cert_pem").(string),
+		ClientCert:         d.Get("client_cert").(string),
+		ClientKey:          d.Get("client_key").(string),
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+	})
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	if !d.Get("skip_credentials_validation").(bool) {
+		user, err := c.GetCurrentUser(ctx)
+		if err != nil {
+			return nil, diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "Unable to authenticate to Shuffle",
+					Detail:   fmt.Sprintf("Check the base URL and the API token. %s", err),
+				},
+			}
+		}
+		log.Printf("[INFO] Authenticated to Shuffle as %s in org %s (%s)", user.Username, user.ActiveOrg.Name, user.ActiveOrg.Id)
+		c.User = &user
+	}
 
 	return c, nil
 }
+
+// loadProfile reads the profile from the shared config file. The file is only
+// required to exist when the profile or the file were explicitly set.
+func loadProfile(d *schema.ResourceData) (client.Profile, error) {
+	profileName := d.Get("shuffle_profile").(string)
+	configFile := d.Get("shuffle_config_file").(string)
+	explicit := profileName != "" || configFile != ""
+
+	if profileName == "" {
+		profileName = client.DefaultProfileName
+	}
+	if configFile == "" {
+		defaultConfigFile, err := client.DefaultConfigFile()
+		if err != nil {
+			return client.Profile{}, nil
+		}
+		configFile = defaultConfigFile
+	}
+
+	profile, err := client.LoadProfile(configFile, profileName)
+	if err != nil {
+		if !explicit {
+			log.Printf("[DEBUG] Ignoring the default Shuffle profile: %s", err)
+			return client.Profile{}, nil
+		}
+		return client.Profile{}, fmt.Errorf("Failed to load the Shuffle profile: %s", err)
+	}
+
+	return profile, nil
+}
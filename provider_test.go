@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatalf("invalid provider schema: %s", err)
+	}
+}
+
+func TestProviderRequiredAndComputed(t *testing.T) {
+	// The resources mark the attributes Shuffle fills as Computed after setting the
+	// statuses by key, which makes an attribute both Required and Computed when the
+	// key was made Required, and only InternalValidate finds it
+	p := Provider()
+	actionSchema := p.ResourcesMap["shufflesoar_workflow"].Schema["actions"].Elem.(*schema.Resource).Schema
+	if !actionSchema["id"].Required {
+		t.Fatalf("expected actions.id to be required")
+	}
+	actionSchema["id"].Computed = true
+
+	if err := p.InternalValidate(); err == nil {
+		t.Fatalf("expected an attribute both Required and Computed to be invalid")
+	}
+}
+
+// setEnv sets the environment variable for the test, unsetting it when value is empty
+func setEnv(t *testing.T, key string, value string) {
+	previous, ok := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+	if value == "" {
+		os.Unsetenv(key)
+	} else {
+		os.Setenv(key, value)
+	}
+}
+
+func TestProviderConfigurePrecedence(t *testing.T) {
+	cases := []struct {
+		name          string
+		config        map[string]interface{}
+		env           map[string]string
+		profile       string
+		expectedUrl   string
+		expectedToken string
+		err           string
+	}{
+		{
+			name:          "arguments first",
+			config:        map[string]interface{}{"shuffle_base_url": "https://argument.example.com", "shuffle_api_token": "argument"},
+			env:           map[string]string{"SHUFFLE_BASE_URL": "https://env.example.com", "SHUFFLE_API_TOKEN": "env"},
+			profile:       "[default]\nbase_url = https://profile.example.com\napi_token = profile\n",
+			expectedUrl:   "https://argument.example.com",
+			expectedToken: "argument",
+		},
+		{
+			name:          "environment variables before the profile",
+			env:           map[string]string{"SHUFFLE_BASE_URL": "https://env.example.com", "SHUFFLE_API_TOKEN": "env"},
+			profile:       "[default]\nbase_url = https://profile.example.com\napi_token = profile\n",
+			expectedUrl:   "https://env.example.com",
+			expectedToken: "env",
+		},
+		{
+			name:          "profile last",
+			profile:       "[default]\nbase_url = https://profile.example.com\napi_token = profile\n",
+			expectedUrl:   "https://profile.example.com",
+			expectedToken: "profile",
+		},
+		{
+			name:          "each setting from its own source",
+			config:        map[string]interface{}{"shuffle_base_url": "https://argument.example.com"},
+			profile:       "[default]\nbase_url = https://profile.example.com\napi_token = profile\n",
+			expectedUrl:   "https://argument.example.com",
+			expectedToken: "profile",
+		},
+		{
+			name:          "named profile",
+			env:           map[string]string{"SHUFFLE_PROFILE": "other"},
+			profile:       "[default]\nbase_url = https://profile.example.com\napi_token = profile\n[other]\nbase_url = https://other.example.com\napi_token = other\n",
+			expectedUrl:   "https://other.example.com",
+			expectedToken: "other",
+		},
+		{
+			name:    "missing named profile",
+			config:  map[string]interface{}{"shuffle_profile": "missing"},
+			profile: "[default]\nbase_url = https://profile.example.com\napi_token = profile\n",
+			err:     "profile (missing) not found",
+		},
+		{
+			name: "missing default profile",
+			err:  "No Shuffle base URL found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The default config file is read from the home directory
+			home := t.TempDir()
+			setEnv(t, "HOME", home)
+			for _, key := range []string{"SHUFFLE_BASE_URL", "SHUFFLE_API_TOKEN", "SHUFFLE_PROFILE", "SHUFFLE_CONFIG_FILE"} {
+				setEnv(t, key, tc.env[key])
+			}
+			if tc.profile != "" {
+				if err := os.MkdirAll(filepath.Join(home, ".shuffle"), 0700); err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+				if err := ioutil.WriteFile(filepath.Join(home, ".shuffle", "config"), []byte(tc.profile), 0600); err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+			}
+
+			config := map[string]interface{}{"skip_credentials_validation": true}
+			for key, value := range tc.config {
+				config[key] = value
+			}
+			p := Provider()
+			d := schema.TestResourceDataRaw(t, p.Schema, config)
+
+			meta, diags := p.ConfigureContextFunc(context.Background(), d)
+			if tc.err != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary, tc.err) {
+					t.Fatalf("expected the error %q, got %+v", tc.err, diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error %+v", diags)
+			}
+			c := meta.(*client.ShuffleClient)
+			if c.BaseUrl != tc.expectedUrl || c.APIToken != tc.expectedToken {
+				t.Fatalf("expected %s and %s, got %s and %s", tc.expectedUrl, tc.expectedToken, c.BaseUrl, c.APIToken)
+			}
+		})
+	}
+}
@@ -1,31 +1,53 @@
 package resources
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
 	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
 )
 
-func randomHex(n int) (string, error) {
-	bytes := make([]byte, n)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
-}
-
 func ResourceAppAuthentication() *schema.Resource {
 	r := &schema.Resource{
-		Create: resourceAppAuthenticationCreate,
-		Read:   resourceAppAuthenticationRead,
-		Update: resourceAppAuthenticationUpdate,
-		Delete: resourceAppAuthenticationDelete,
+		CreateContext: resourceAppAuthenticationCreate,
+		ReadContext:   resourceAppAuthenticationRead,
+		UpdateContext: resourceAppAuthenticationUpdate,
+		DeleteContext: resourceAppAuthenticationDelete,
+		CustomizeDiff: resourceAppAuthenticationCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceAppAuthenticationImport,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 
 		Schema: client.GetDefaultAppSchema().Schema,
+
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceAppAuthenticationV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceAppAuthenticationStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceAppAuthenticationV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceAppAuthenticationStateUpgradeV1,
+			},
+		},
 	}
 
 	r = utils.RecurseSetSchemaStatus(r, utils.Optional, true)
@@ -33,129 +55,422 @@ func ResourceAppAuthentication() *schema.Resource {
 	r = utils.RecurseSetSchemaStatusByKey(r, "id", utils.Computed, true)
 	r = utils.RecurseSetSchemaStatusByKey(r, "label", utils.Required, true)
 	r = utils.RecurseSetSchemaStatusByKey(r, "app.name", utils.Required, true)
-	r = utils.RecurseSetSchemaStatusByKey(r, "fields.key", utils.Required, true)
-	r = utils.RecurseSetSchemaStatusByKey(r, "fields.value", utils.Required, true)
+
+	// The fields are keyed by their name, so their order doesn't matter and a key can't be repeated
+	r.Schema["fields"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+		Description:      "The values of the authentication fields of the app, by key. The keys must match the names in the authentication parameters of the app.",
+		Sensitive:        true,
+		ValidateDiagFunc: validateFieldKeys,
+		DiffSuppressFunc: suppressMaskedValue,
+		AtLeastOneOf:     []string{"fields", "oauth2"},
+	}
+	r.Schema["oauth2"] = getOAuth2Schema()
 
 	r.Schema["app"].MinItems = 1
 	r.Schema["app"].MaxItems = 1
 
+	r.Schema["active"].Default = true
+	// The app is found by name in the catalog of Shuffle, which fills the rest
+	appSchema := r.Schema["app"].Elem.(*schema.Resource).Schema
+	appSchema["id"].Computed = true
+	appSchema["app_version"].Computed = true
+	appSchema["large_image"].Computed = true
+
+	r.Schema["force_delete"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Detach the authentication from the workflows using it when Shuffle refuses to delete it because it is in use.",
+	}
+
 	return r
 }
 
+// resourceAppAuthenticationImport accepts either the auth id, "label:<label>" or "<app_name>/<label>"
+func resourceAppAuthenticationImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c := m.(*client.ShuffleClient)
+
+	importId := d.Id()
+
+	var app client.App
+	var err error
+	if strings.HasPrefix(importId, "label:") {
+		app, err = c.GetAppAuthByLabel(ctx, "", strings.TrimPrefix(importId, "label:"))
+	} else if parts := strings.SplitN(importId, "/", 2); len(parts) == 2 {
+		app, err = c.GetAppAuthByLabel(ctx, parts[0], parts[1])
+	} else {
+		app, err = c.GetAppAuthById(ctx, importId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(app.Id)
+	d.Set("force_delete", false)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceAppAuthenticationCustomizeDiff checks the fields against the authentication
+// parameters of the app, so mistakes are found at plan time instead of during the
+// workflow executions
+func resourceAppAuthenticationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" && !d.HasChange("fields") && !d.HasChange("app") && !d.HasChange("oauth2") {
+		return nil
+	}
+	if !d.NewValueKnown("app.0.name") || !d.NewValueKnown("fields") {
+		return nil
+	}
+
+	c := m.(*client.ShuffleClient)
+
+	config := d.GetRawConfig()
+	version := getConfiguredAppAttribute(config, "app_version")
+	if version == "" && d.Id() != "" && !d.HasChange("app.0.name") {
+		version = d.Get("app.0.app_version").(string)
+	}
+	app, err := c.GetAppByName(ctx, d.Get("app.0.name").(string), version, getConfiguredAppAttribute(config, "id"))
+	if err != nil {
+		return err
+	}
+
+	oauth2 := d.Get("oauth2").([]interface{})
+	if len(oauth2) > 0 && oauth2[0] != nil {
+		if err := validateOAuth2(app, oauth2[0].(map[string]interface{}), d.NewValueKnown("oauth2.0.refresh_token")); err != nil {
+			return err
+		}
+		// The OAuth2 settings take the place of the required parameters
+		return validateFields(app, d.Get("fields").(map[string]interface{}), false)
+	}
+
+	return validateFields(app, d.Get("fields").(map[string]interface{}), true)
+}
+
+// getConfiguredAppAttribute returns the attribute of the app block from the config, the
+// planned value being the one of the state when the attribute isn't configured
+func getConfiguredAppAttribute(config cty.Value, name string) string {
+	return getConfiguredBlockAttribute(config, "app", name)
+}
+
+// getConfiguredBlockAttribute returns the string attribute of a single block from the config
+func getConfiguredBlockAttribute(config cty.Value, block string, name string) string {
+	if config.IsNull() || !config.IsKnown() {
+		return ""
+	}
+	blocks := config.GetAttr(block)
+	if blocks.IsNull() || !blocks.IsKnown() || blocks.LengthInt() == 0 {
+		return ""
+	}
+	value := blocks.Index(cty.NumberIntVal(0)).GetAttr(name)
+	if !isKnownString(value) {
+		return ""
+	}
+	return value.AsString()
+}
+
+func validateFields(app client.AppAuthentication, fields map[string]interface{}, requireParameters bool) error {
+	parameters := make(map[string]client.AuthenticationParameter)
+	names := []string{}
+	for _, p := range app.Authentication.Parameters {
+		parameters[p.Name] = p
+		names = append(names, p.Name)
+	}
+
+	var errs []string
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p, ok := parameters[key]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("the field (%s) is not an authentication parameter of the app, expected one of: %s", key, strings.Join(names, ", ")))
+			continue
+		}
+		if value, _ := fields[key].(string); !p.Multiline && strings.Contains(value, "\n") {
+			errs = append(errs, fmt.Sprintf("the field (%s) can't span multiple lines", key))
+		}
+	}
+	for _, name := range names {
+		if _, ok := fields[name]; !ok && parameters[name].Required && requireParameters {
+			errs = append(errs, fmt.Sprintf("the required field (%s) is missing", name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid fields for the app %s (%s):\n- %s", app.Name, app.AppVersion, strings.Join(errs, "\n- "))
+	}
+	return nil
+}
+
 func getAppAuthFromResourceData(d *schema.ResourceData) map[string]interface{} {
 	return d.Get("app").([]interface{})[0].(map[string]interface{})
 }
 
-func createAppObj(d *schema.ResourceData) (client.App, error) {
+func createAppObj(ctx context.Context, c *client.ShuffleClient, d *schema.ResourceData) (client.App, error) {
 	appAuth := getAppAuthFromResourceData(d)
-	appAuthId := appAuth["id"].(string)
-	if appAuthId == "" {
-		appAuthId, _ = randomHex(16)
-		appAuth["id"] = appAuthId
-		temp := make([]interface{}, 1)
-		temp[0] = appAuth
-		d.Set("app", temp)
+
+	config := d.GetRawConfig()
+	version := getConfiguredAppAttribute(config, "app_version")
+	if version == "" && d.Id() != "" && !d.HasChange("app.0.name") {
+		// Keep the version the authentication was created with when it isn't pinned
+		version = appAuth["app_version"].(string)
+	}
+	catalogApp, err := c.GetAppByName(ctx, appAuth["name"].(string), version, getConfiguredAppAttribute(config, "id"))
+	if err != nil {
+		return client.App{}, err
+	}
+	log.Printf("[INFO] App (%s) resolved to %s version %s", catalogApp.Name, catalogApp.Id, catalogApp.AppVersion)
+
+	largeImage := getConfiguredAppAttribute(config, "large_image")
+	if largeImage == "" {
+		largeImage = catalogApp.LargeImage
 	}
-	log.Printf("[INFO] appAuthId: %s", appAuthId)
 
 	app := client.App{
 		App: client.AppAuthentication{
-			Name:       appAuth["name"].(string),
-			Id:         appAuthId,
-			LargeImage: appAuth["large_image"].(string),
+			Name:       catalogApp.Name,
+			Id:         catalogApp.Id,
+			AppVersion: catalogApp.AppVersion,
+			LargeImage: largeImage,
 		},
 		Fields: []client.Field{},
 		Label:  d.Get("label").(string),
-		Active: true,
+		Active: d.Get("active").(bool),
 	}
 
-	fieldsResources := d.Get("fields").([]interface{})
-	for _, fr := range fieldsResources {
+	configuredValues := getConfiguredFieldValues(d)
+	fields := d.Get("fields").(map[string]interface{})
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fields[key].(string)
+		if value == client.MaskedFieldValue {
+			// The planned value is the one from the import, send the configured one instead
+			value = configuredValues[key]
+		}
 		app.Fields = append(app.Fields, client.Field{
-			Key:   fr.(map[string]interface{})["key"].(string),
-			Value: fr.(map[string]interface{})["value"].(string),
+			Key:   key,
+			Value: value,
 		})
 	}
 
+	if appType, oauth2Fields := expandOAuth2(d, catalogApp); appType != "" {
+		app.Type = appType
+		app.Fields = append(app.Fields, oauth2Fields...)
+	}
+
 	return app, nil
 }
 
-func resourceAppAuthenticationCreate(d *schema.ResourceData, m interface{}) error {
+func getConfiguredFieldValues(d *schema.ResourceData) map[string]string {
+	values := make(map[string]string)
+
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return values
+	}
+	fields := config.GetAttr("fields")
+	if fields.IsNull() || !fields.IsKnown() {
+		return values
+	}
+
+	for it := fields.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		if !isKnownString(key) || !isKnownString(value) {
+			continue
+		}
+		values[key.AsString()] = value.AsString()
+	}
+
+	return values
+}
+
+func isKnownString(v cty.Value) bool {
+	return v.IsKnown() && !v.IsNull() && v.Type() == cty.String
+}
+
+func validateFieldKeys(v interface{}, path cty.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for key := range v.(map[string]interface{}) {
+		if strings.TrimSpace(key) == "" {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid field key",
+				Detail:        "The keys of fields can't be empty.",
+				AttributePath: path,
+			})
+		}
+	}
+	return diags
+}
+
+// getStateFieldValues returns the field values from the state, by key
+func getStateFieldValues(d *schema.ResourceData) map[string]string {
+	values := make(map[string]string)
+	for key, value := range d.Get("fields").(map[string]interface{}) {
+		values[key] = value.(string)
+	}
+	return values
+}
+
+// flattenApp returns the app block of the authentication
+func flattenApp(app client.App) []map[string]interface{} {
+	appAuth := make([]map[string]interface{}, 1)
+	appAuth[0] = make(map[string]interface{})
+	appAuth[0]["id"] = app.App.Id
+	appAuth[0]["name"] = app.App.Name
+	appAuth[0]["app_version"] = app.App.AppVersion
+	appAuth[0]["large_image"] = app.App.LargeImage
+	return appAuth
+}
+
+// flattenFields returns the fields of the app, the values Shuffle masks or encrypts being taken from the state
+func flattenFields(app client.App, stateValues map[string]string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(app.Fields))
+	for _, f := range app.Fields {
+		if isOAuth2(app) && oauth2FieldKeys[f.Key] {
+			continue
+		}
+		value := f.Value
+		if stateValue, ok := stateValues[f.Key]; ok && (value == client.MaskedFieldValue || app.Encrypted) {
+			value = stateValue
+		}
+		fields[f.Key] = value
+	}
+	return fields
+}
+
+func resourceAppAuthenticationCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*client.ShuffleClient)
 
-	app, err := createAppObj(d)
+	app, err := createAppObj(ctx, c, d)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	id, err := c.CreateOrUpdateAppAuth(app)
+	id, err := c.CreateOrUpdateAppAuth(ctx, app)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	d.SetId(id)
+	// The app was resolved from the catalog, keep what it resolved to
+	d.Set("app", flattenApp(app))
+	d.Set("fields", flattenFields(app, map[string]string{}))
+	d.Set("oauth2", flattenOAuth2(app, d.Get("oauth2").([]interface{})))
 
 	return nil
 }
 
-func resourceAppAuthenticationRead(d *schema.ResourceData, m interface{}) error {
+func resourceAppAuthenticationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	id := d.Id()
 
 	c := m.(*client.ShuffleClient)
 
-	app, err := c.GetAppAuthById(id)
-	if err != nil {
+	app, err := c.GetAppAuthById(ctx, id)
+	if client.IsNotFound(err) {
 		log.Printf("[WARN] App (%s) not found, removing from state", id)
 		d.SetId("")
 		return nil
 	}
-
-	appAuth := make([]map[string]interface{}, 1)
-	appAuth[0] = make(map[string]interface{})
-	appAuth[0]["id"] = app.App.Id
-	appAuth[0]["name"] = app.App.Name
-	appAuth[0]["large_image"] = app.App.LargeImage
-
-	fields := make([]map[string]interface{}, len(app.Fields))
-	for _, f := range app.Fields {
-		f1 := make(map[string]interface{})
-		f1["key"] = f.Key
-		f1["value"] = f.Value
-		fields = append(fields, f1)
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
-	d.Set("app", appAuth)
-	d.Set("name", app.App.Name)
+	d.Set("app", flattenApp(app))
 	d.Set("label", app.Label)
-	d.Set("fields", fields)
+	d.Set("active", app.Active)
+	d.Set("fields", flattenFields(app, getStateFieldValues(d)))
+	d.Set("oauth2", flattenOAuth2(app, d.Get("oauth2").([]interface{})))
 
 	return nil
 }
 
-func resourceAppAuthenticationUpdate(d *schema.ResourceData, m interface{}) error {
+func resourceAppAuthenticationUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*client.ShuffleClient)
 
-	app, err := createAppObj(d)
+	app, err := createAppObj(ctx, c, d)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	app.Id = d.Id()
 
-	_, err = c.CreateOrUpdateAppAuth(app)
+	_, err = c.CreateOrUpdateAppAuth(ctx, app)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
+	// Keep the values that were sent, as some may only have been known from the config
+	d.Set("app", flattenApp(app))
+	d.Set("fields", flattenFields(app, map[string]string{}))
+	d.Set("oauth2", flattenOAuth2(app, d.Get("oauth2").([]interface{})))
+
 	return nil
 }
 
-func resourceAppAuthenticationDelete(d *schema.ResourceData, m interface{}) error {
+func resourceAppAuthenticationDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*client.ShuffleClient)
 
 	id := d.Id()
 
-	c.DeleteAppAuth(id)
+	err := c.DeleteAppAuth(ctx, id)
+	if err != nil && !client.IsNotFound(err) {
+		// Shuffle refuses to delete an authentication still used by workflows, which
+		// its usage tells more reliably than the reason of the refusal
+		app, getErr := c.GetAppAuthById(ctx, id)
+		if getErr != nil || appAuthWorkflowCount(app) == 0 {
+			return diag.FromErr(err)
+		}
+		if !d.Get("force_delete").(bool) {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("App authentication (%s) is still used by %d workflow(s)", id, appAuthWorkflowCount(app)),
+				Detail:   fmt.Sprintf("%s\n\nRemove it from the workflows or set force_delete = true to detach it from them.", err),
+			}}
+		}
+
+		if err := c.DetachAppAuthFromWorkflows(ctx, app); err != nil {
+			return diag.FromErr(err)
+		}
+		err = c.DeleteAppAuth(ctx, id)
+	}
+	if err != nil && !client.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	// Shuffle may answer before the authentication is gone, make sure it is
+	err = resource.RetryContext(ctx, d.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
+		_, err := c.GetAppAuthById(ctx, id)
+		if client.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+		return resource.RetryableError(fmt.Errorf("App authentication (%s) still exists after delete", id))
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	d.SetId("")
 	return nil
 }
+
+// appAuthWorkflowCount returns the number of workflows using the app authentication
+func appAuthWorkflowCount(app client.App) int {
+	if len(app.Usage) > app.WorkflowCount {
+		return len(app.Usage)
+	}
+	return app.WorkflowCount
+}
@@ -0,0 +1,502 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceAppAuthenticationV0 is the schema where the fields were a list of key/value blocks.
+// The schemas of the previous versions are frozen, so later changes to the client structs
+// don't alter the type of the states to upgrade.
+func resourceAppAuthenticationV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"app": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"action_file_path": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"activated": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"app_version": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"authentication": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"client_id": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"client_secret": {
+										Type:      schema.TypeString,
+										Optional:  true,
+										Sensitive: true,
+									},
+									"parameters": {
+										Type:     schema.TypeList,
+										Optional: true,
+										Elem: &schema.Resource{
+											Schema: map[string]*schema.Schema{
+												"description": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"example": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"id": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"in": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"multiline": {
+													Type:     schema.TypeBool,
+													Optional: true,
+												},
+												"name": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+												"required": {
+													Type:     schema.TypeBool,
+													Optional: true,
+												},
+												"schema": {
+													Type:     schema.TypeList,
+													Optional: true,
+													Elem: &schema.Resource{
+														Schema: map[string]*schema.Schema{
+															"type": {
+																Type:     schema.TypeString,
+																Optional: true,
+															},
+														},
+													},
+												},
+												"scheme": {
+													Type:     schema.TypeString,
+													Optional: true,
+												},
+											},
+										},
+									},
+									"redirect_uri": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"refresh_uri": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"required": {
+										Type:     schema.TypeBool,
+										Optional: true,
+									},
+									"token_uri": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"type": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+						"categories": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"contact_info": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"url": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+						"created": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"documentation": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"downloaded": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"edited": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"environment": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"folder_mount": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"destination_folder": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"folder_mount": {
+										Type:     schema.TypeBool,
+										Optional: true,
+									},
+									"source_folder": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+						"generated": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"hash": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"id": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"invalid": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"is_valid": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"large_image": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"last_runtime": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"link": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"loop_versions": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"owner": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"private_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"public": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"reference_info": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"documentation_url": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"github_url": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+						"reference_org": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"reference_url": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"sharing": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"sharing_config": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"small_image": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"tags": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"tested": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"verified": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"versions": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"id": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"version": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"created": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"defined": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"edited": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"encrypted": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"fields": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:      schema.TypeString,
+							Required:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
+			"force_delete": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"label": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"node_count": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"referenceworkflow": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"usage": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"nodes": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"workflow_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"workflow_count": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+		},
+	}
+}
+
+// resourceAppAuthenticationV1 is the schema where the fields were already a map, but some
+// attributes of the app didn't match the ones returned by Shuffle
+func resourceAppAuthenticationV1() *schema.Resource {
+	r := resourceAppAuthenticationV0()
+
+	r.Schema["fields"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+	r.Schema["oauth2"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"grant_type": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"client_id": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"client_secret": {
+					Type:      schema.TypeString,
+					Required:  true,
+					Sensitive: true,
+				},
+				"refresh_token": {
+					Type:      schema.TypeString,
+					Optional:  true,
+					Sensitive: true,
+				},
+				"token_uri": {
+					Type:     schema.TypeString,
+					Optional: true,
+					Computed: true,
+				},
+				"redirect_uri": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"scopes": {
+					Type:     schema.TypeList,
+					Optional: true,
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				},
+			},
+		},
+	}
+
+	return r
+}
+
+// resourceAppAuthenticationStateUpgradeV1 turns the tags, categories and loop versions of the
+// app into lists and renames referenceworkflow to reference_workflow
+func resourceAppAuthenticationStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	apps, _ := rawState["app"].([]interface{})
+	for _, a := range apps {
+		app, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"tags", "categories", "loop_versions"} {
+			value, _ := app[key].(string)
+			if value == "" {
+				app[key] = nil
+				continue
+			}
+			app[key] = []interface{}{value}
+		}
+	}
+
+	rawState["reference_workflow"] = rawState["referenceworkflow"]
+	delete(rawState, "referenceworkflow")
+
+	return rawState, nil
+}
+
+// resourceAppAuthenticationStateUpgradeV0 turns the list of fields into a map. A key can only
+// be kept once, so a state with duplicated keys is rejected instead of silently dropping values.
+func resourceAppAuthenticationStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	list, _ := rawState["fields"].([]interface{})
+	for _, f := range list {
+		field, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := field["key"].(string)
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("App authentication (%v) has the field (%s) more than once, remove the duplicates from the configuration and the state before upgrading", rawState["id"], key)
+		}
+		fields[key] = field["value"]
+	}
+
+	rawState["fields"] = fields
+
+	return rawState, nil
+}
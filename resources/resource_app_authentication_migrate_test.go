@@ -0,0 +1,113 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+)
+
+func testAppAuthenticationStateV0() map[string]interface{} {
+	return map[string]interface{}{
+		"id":     "auth1",
+		"label":  "auth",
+		"active": true,
+		"app": []interface{}{
+			map[string]interface{}{
+				"name":          "http",
+				"tags":          "Testing",
+				"categories":    "",
+				"loop_versions": "1.0.0",
+			},
+		},
+		"fields": []interface{}{
+			map[string]interface{}{"key": "url", "value": "https://example.com"},
+			map[string]interface{}{"key": "apikey", "value": "secret"},
+		},
+		"referenceworkflow": "w1",
+	}
+}
+
+func TestAppAuthenticationStateUpgradeV0(t *testing.T) {
+	state, err := resourceAppAuthenticationStateUpgradeV0(context.Background(), testAppAuthenticationStateV0(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	expected := map[string]interface{}{"url": "https://example.com", "apikey": "secret"}
+	if !reflect.DeepEqual(state["fields"], expected) {
+		t.Fatalf("expected the fields %v, got %v", expected, state["fields"])
+	}
+}
+
+func TestAppAuthenticationStateUpgradeV0WithoutFields(t *testing.T) {
+	rawState := testAppAuthenticationStateV0()
+	delete(rawState, "fields")
+
+	state, err := resourceAppAuthenticationStateUpgradeV0(context.Background(), rawState, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if fields, ok := state["fields"].(map[string]interface{}); !ok || len(fields) != 0 {
+		t.Fatalf("expected no fields, got %v", state["fields"])
+	}
+}
+
+func TestAppAuthenticationStateUpgradeV0DuplicateKeys(t *testing.T) {
+	rawState := testAppAuthenticationStateV0()
+	rawState["fields"] = append(rawState["fields"].([]interface{}), map[string]interface{}{"key": "url", "value": "https://other.example.com"})
+
+	_, err := resourceAppAuthenticationStateUpgradeV0(context.Background(), rawState, nil)
+	if err == nil || !strings.Contains(err.Error(), "(url) more than once") {
+		t.Fatalf("expected an error for the duplicated key, got %v", err)
+	}
+}
+
+func TestAppAuthenticationStateUpgradeV1(t *testing.T) {
+	state, err := resourceAppAuthenticationStateUpgradeV0(context.Background(), testAppAuthenticationStateV0(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	state, err = resourceAppAuthenticationStateUpgradeV1(context.Background(), state, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if _, ok := state["referenceworkflow"]; ok || state["reference_workflow"] != "w1" {
+		t.Fatalf("expected referenceworkflow to be renamed reference_workflow, got %v", state)
+	}
+	app := state["app"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(app["tags"], []interface{}{"Testing"}) || !reflect.DeepEqual(app["loop_versions"], []interface{}{"1.0.0"}) || app["categories"] != nil {
+		t.Fatalf("expected the tags, categories and loop versions to become lists, got %v", app)
+	}
+
+	// The upgraded state must fit the current schema
+	stateJson, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if _, err := ctyjson.Unmarshal(stateJson, ResourceAppAuthentication().CoreConfigSchema().ImpliedType()); err != nil {
+		t.Fatalf("the upgraded state doesn't fit the current schema: %s", err)
+	}
+}
+
+func TestAppAuthenticationStateUpgradeTypes(t *testing.T) {
+	// The states are decoded with the type of their version before being upgraded
+	for version, upgrader := range ResourceAppAuthentication().StateUpgraders {
+		if upgrader.Version != version {
+			t.Fatalf("expected the upgrader %d to be for the version %d, got %d", version, version, upgrader.Version)
+		}
+	}
+	v0 := resourceAppAuthenticationV0().CoreConfigSchema().ImpliedType()
+	if !v0.AttributeType("fields").IsListType() || !v0.AttributeType("app").ElementType().AttributeType("tags").Equals(cty.String) {
+		t.Fatalf("unexpected V0 type %#v", v0)
+	}
+	v1 := resourceAppAuthenticationV1().CoreConfigSchema().ImpliedType()
+	if !v1.AttributeType("fields").IsMapType() || !v1.HasAttribute("referenceworkflow") {
+		t.Fatalf("unexpected V1 type %#v", v1)
+	}
+}
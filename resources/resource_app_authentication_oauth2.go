@@ -0,0 +1,199 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+const (
+	oauth2GrantClientCredentials = "client_credentials"
+	oauth2GrantRefreshToken      = "refresh_token"
+
+	// The types Shuffle gives to the OAuth2 authentications, the client credentials
+	// being obtained by Shuffle itself while the others come from a user consent
+	oauth2AppType               = "oauth2"
+	oauth2ClientCredentialsType = "oauth2-app"
+)
+
+// oauth2FieldKeys are the fields in which Shuffle stores the OAuth2 settings and tokens
+var oauth2FieldKeys = map[string]bool{
+	"client_id":     true,
+	"client_secret": true,
+	"token_uri":     true,
+	"redirect_uri":  true,
+	"scope":         true,
+	"grant_type":    true,
+	"refresh_token": true,
+	"access_token":  true,
+	"expiration":    true,
+}
+
+// Shuffle never returns the secrets, so an imported value is unknown and considered
+// to be the configured one
+func suppressMaskedValue(k, old, new string, d *schema.ResourceData) bool {
+	return old == client.MaskedFieldValue && new != ""
+}
+
+func getOAuth2Schema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "The OAuth2 settings, for the apps using OAuth2 (i.e Microsoft Graph or Google Workspace) instead of static fields",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"grant_type": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ValidateFunc: validation.StringInSlice([]string{oauth2GrantClientCredentials, oauth2GrantRefreshToken}, false),
+					Description:  "`client_credentials` for Shuffle to get the tokens with the client ID and secret, or `refresh_token` to seed an app using the authorization code flow with a refresh token",
+				},
+				"client_id": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"client_secret": {
+					Type:             schema.TypeString,
+					Required:         true,
+					Sensitive:        true,
+					DiffSuppressFunc: suppressMaskedValue,
+				},
+				"refresh_token": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Sensitive:        true,
+					DiffSuppressFunc: suppressMaskedValue,
+					Description:      "The refresh token obtained from a user consent. Required with the `refresh_token` grant type",
+				},
+				"token_uri": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Computed:    true,
+					Description: "The token endpoint. The one of the app is used when not set",
+				},
+				"redirect_uri": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The redirect URI registered for the client, when the token endpoint requires it",
+				},
+				"scopes": {
+					Type:     schema.TypeList,
+					Optional: true,
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				},
+			},
+		},
+	}
+}
+
+func isOAuth2(app client.App) bool {
+	return app.Type == oauth2AppType || app.Type == oauth2ClientCredentialsType
+}
+
+// expandOAuth2 returns the type of the authentication and the fields holding the OAuth2 settings
+func expandOAuth2(d *schema.ResourceData, catalogApp client.AppAuthentication) (string, []client.Field) {
+	list := d.Get("oauth2").([]interface{})
+	if len(list) == 0 || list[0] == nil {
+		return "", nil
+	}
+	o := list[0].(map[string]interface{})
+
+	config := d.GetRawConfig()
+	secret := func(name string) string {
+		value := o[name].(string)
+		if value == client.MaskedFieldValue {
+			// The planned value is the one from the import, send the configured one instead
+			value = getConfiguredBlockAttribute(config, "oauth2", name)
+		}
+		return value
+	}
+
+	tokenUri := o["token_uri"].(string)
+	if tokenUri == "" {
+		tokenUri = catalogApp.Authentication.TokenUri
+	}
+	scopes := []string{}
+	for _, s := range o["scopes"].([]interface{}) {
+		scopes = append(scopes, s.(string))
+	}
+
+	fields := []client.Field{
+		{Key: "grant_type", Value: o["grant_type"].(string)},
+		{Key: "client_id", Value: o["client_id"].(string)},
+		{Key: "client_secret", Value: secret("client_secret")},
+		{Key: "token_uri", Value: tokenUri},
+		{Key: "scope", Value: strings.Join(scopes, " ")},
+	}
+	if redirectUri := o["redirect_uri"].(string); redirectUri != "" {
+		fields = append(fields, client.Field{Key: "redirect_uri", Value: redirectUri})
+	}
+
+	if o["grant_type"].(string) == oauth2GrantClientCredentials {
+		return oauth2ClientCredentialsType, fields
+	}
+
+	fields = append(fields, client.Field{Key: "refresh_token", Value: secret("refresh_token")})
+	return oauth2AppType, fields
+}
+
+// flattenOAuth2 returns the OAuth2 settings from the fields of the app, the secrets
+// Shuffle masks being taken from the state
+func flattenOAuth2(app client.App, state []interface{}) []map[string]interface{} {
+	if !isOAuth2(app) {
+		return nil
+	}
+
+	stateValues := map[string]interface{}{}
+	if len(state) > 0 && state[0] != nil {
+		stateValues = state[0].(map[string]interface{})
+	}
+	values := make(map[string]string)
+	for _, f := range app.Fields {
+		values[f.Key] = f.Value
+		if stateValue, ok := stateValues[f.Key].(string); ok && stateValue != "" && (f.Value == client.MaskedFieldValue || app.Encrypted) {
+			values[f.Key] = stateValue
+		}
+	}
+
+	grantType := values["grant_type"]
+	if grantType == "" {
+		grantType = oauth2GrantRefreshToken
+		if app.Type == oauth2ClientCredentialsType {
+			grantType = oauth2GrantClientCredentials
+		}
+	}
+	// Shuffle refreshes the token, so the one in the state is only the seed
+	refreshToken, _ := stateValues["refresh_token"].(string)
+	if refreshToken == "" && values["refresh_token"] == client.MaskedFieldValue {
+		refreshToken = client.MaskedFieldValue
+	}
+
+	return []map[string]interface{}{{
+		"grant_type":    grantType,
+		"client_id":     values["client_id"],
+		"client_secret": values["client_secret"],
+		"refresh_token": refreshToken,
+		"token_uri":     values["token_uri"],
+		"redirect_uri":  values["redirect_uri"],
+		"scopes": strings.FieldsFunc(values["scope"], func(r rune) bool {
+			return r == ' ' || r == ','
+		}),
+	}}
+}
+
+// validateOAuth2 checks that the app uses OAuth2 and that the settings fit the grant type
+func validateOAuth2(app client.AppAuthentication, oauth2 map[string]interface{}, refreshTokenKnown bool) error {
+	if !strings.EqualFold(app.Authentication.Type, oauth2AppType) {
+		return fmt.Errorf("The app %s (%s) doesn't use OAuth2 but %s, set its fields instead", app.Name, app.AppVersion, app.Authentication.Type)
+	}
+	if oauth2["grant_type"] == oauth2GrantRefreshToken && refreshTokenKnown && oauth2["refresh_token"] == "" {
+		return fmt.Errorf("oauth2.refresh_token is required with the %s grant type", oauth2GrantRefreshToken)
+	}
+	return nil
+}
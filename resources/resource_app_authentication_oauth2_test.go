@@ -0,0 +1,283 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+func testOAuth2CatalogApp() client.AppAuthentication {
+	return client.AppAuthentication{
+		Name:       "graph",
+		Id:         "graph1",
+		AppVersion: "1.0.0",
+		Authentication: client.Authentication{
+			Type:     "oauth2",
+			TokenUri: "https://login.example.com/token",
+		},
+	}
+}
+
+func testOAuth2ResourceData(t *testing.T, oauth2 map[string]interface{}) *schema.ResourceData {
+	t.Helper()
+	return schema.TestResourceDataRaw(t, ResourceAppAuthentication().Schema, map[string]interface{}{
+		"label":  "auth",
+		"app":    []interface{}{map[string]interface{}{"name": "graph"}},
+		"oauth2": []interface{}{oauth2},
+	})
+}
+
+func TestExpandOAuth2(t *testing.T) {
+	cases := []struct {
+		name         string
+		oauth2       map[string]interface{}
+		expectedType string
+		expected     []client.Field
+	}{
+		{
+			name: "client credentials",
+			oauth2: map[string]interface{}{
+				"grant_type":    "client_credentials",
+				"client_id":     "client",
+				"client_secret": "secret",
+				"scopes":        []interface{}{"openid", "offline_access"},
+			},
+			expectedType: "oauth2-app",
+			expected: []client.Field{
+				{Key: "grant_type", Value: "client_credentials"},
+				{Key: "client_id", Value: "client"},
+				{Key: "client_secret", Value: "secret"},
+				{Key: "token_uri", Value: "https://login.example.com/token"},
+				{Key: "scope", Value: "openid offline_access"},
+			},
+		},
+		{
+			name: "refresh token",
+			oauth2: map[string]interface{}{
+				"grant_type":    "refresh_token",
+				"client_id":     "client",
+				"client_secret": "secret",
+				"refresh_token": "token",
+				"token_uri":     "https://example.com/token",
+				"redirect_uri":  "https://example.com/callback",
+			},
+			expectedType: "oauth2",
+			expected: []client.Field{
+				{Key: "grant_type", Value: "refresh_token"},
+				{Key: "client_id", Value: "client"},
+				{Key: "client_secret", Value: "secret"},
+				{Key: "token_uri", Value: "https://example.com/token"},
+				{Key: "scope", Value: ""},
+				{Key: "redirect_uri", Value: "https://example.com/callback"},
+				{Key: "refresh_token", Value: "token"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			authType, fields := expandOAuth2(testOAuth2ResourceData(t, tc.oauth2), testOAuth2CatalogApp())
+			if authType != tc.expectedType {
+				t.Fatalf("expected the type %s, got %s", tc.expectedType, authType)
+			}
+			if !reflect.DeepEqual(fields, tc.expected) {
+				t.Fatalf("expected the fields %+v, got %+v", tc.expected, fields)
+			}
+		})
+	}
+}
+
+func TestExpandOAuth2NotSet(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ResourceAppAuthentication().Schema, map[string]interface{}{"label": "auth"})
+	if authType, fields := expandOAuth2(d, testOAuth2CatalogApp()); authType != "" || fields != nil {
+		t.Fatalf("expected no OAuth2 settings, got %s %+v", authType, fields)
+	}
+}
+
+func TestExpandOAuth2MaskedSecrets(t *testing.T) {
+	// After an import the state holds the masked secrets, the configured ones are sent instead
+	state := &terraform.InstanceState{
+		ID: "auth1",
+		Attributes: map[string]string{
+			"oauth2.#":               "1",
+			"oauth2.0.grant_type":    "refresh_token",
+			"oauth2.0.client_id":     "client",
+			"oauth2.0.client_secret": client.MaskedFieldValue,
+			"oauth2.0.refresh_token": client.MaskedFieldValue,
+			"oauth2.0.token_uri":     "https://example.com/token",
+		},
+		RawConfig: cty.ObjectVal(map[string]cty.Value{
+			"oauth2": cty.ListVal([]cty.Value{cty.ObjectVal(map[string]cty.Value{
+				"client_secret": cty.StringVal("secret"),
+				"refresh_token": cty.StringVal("token"),
+			})}),
+		}),
+	}
+	_, fields := expandOAuth2(ResourceAppAuthentication().Data(state), testOAuth2CatalogApp())
+
+	values := make(map[string]string)
+	for _, f := range fields {
+		values[f.Key] = f.Value
+	}
+	if values["client_secret"] != "secret" || values["refresh_token"] != "token" {
+		t.Fatalf("expected the configured secrets, got %+v", fields)
+	}
+}
+
+func TestFlattenOAuth2(t *testing.T) {
+	cases := []struct {
+		name     string
+		app      client.App
+		state    []interface{}
+		expected []map[string]interface{}
+	}{
+		{
+			name: "not OAuth2",
+			app:  client.App{Type: "api-key", Fields: []client.Field{{Key: "apikey", Value: "secret"}}},
+		},
+		{
+			name: "client credentials with masked secret",
+			app: client.App{Type: "oauth2-app", Fields: []client.Field{
+				{Key: "client_id", Value: "client"},
+				{Key: "client_secret", Value: client.MaskedFieldValue},
+				{Key: "token_uri", Value: "https://example.com/token"},
+				{Key: "scope", Value: "openid,offline_access email"},
+			}},
+			state: []interface{}{map[string]interface{}{"client_secret": "secret"}},
+			expected: []map[string]interface{}{{
+				"grant_type":    "client_credentials",
+				"client_id":     "client",
+				"client_secret": "secret",
+				"refresh_token": "",
+				"token_uri":     "https://example.com/token",
+				"redirect_uri":  "",
+				"scopes":        []string{"openid", "offline_access", "email"},
+			}},
+		},
+		{
+			name: "imported refresh token",
+			app: client.App{Type: "oauth2", Fields: []client.Field{
+				{Key: "client_id", Value: "client"},
+				{Key: "client_secret", Value: client.MaskedFieldValue},
+				{Key: "refresh_token", Value: client.MaskedFieldValue},
+			}},
+			expected: []map[string]interface{}{{
+				"grant_type":    "refresh_token",
+				"client_id":     "client",
+				"client_secret": client.MaskedFieldValue,
+				"refresh_token": client.MaskedFieldValue,
+				"token_uri":     "",
+				"redirect_uri":  "",
+				"scopes":        []string{},
+			}},
+		},
+		{
+			name: "encrypted values",
+			app: client.App{Type: "oauth2", Encrypted: true, Fields: []client.Field{
+				{Key: "grant_type", Value: "refresh_token"},
+				{Key: "client_id", Value: "client"},
+				{Key: "client_secret", Value: "encrypted"},
+				{Key: "refresh_token", Value: "refreshed"},
+			}},
+			state: []interface{}{map[string]interface{}{"client_secret": "secret", "refresh_token": "seed"}},
+			expected: []map[string]interface{}{{
+				"grant_type":    "refresh_token",
+				"client_id":     "client",
+				"client_secret": "secret",
+				"refresh_token": "seed",
+				"token_uri":     "",
+				"redirect_uri":  "",
+				"scopes":        []string{},
+			}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if oauth2 := flattenOAuth2(tc.app, tc.state); !reflect.DeepEqual(oauth2, tc.expected) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, oauth2)
+			}
+		})
+	}
+}
+
+func TestOAuth2ScopesRoundTrip(t *testing.T) {
+	scopes := []interface{}{"openid", "offline_access", "https://graph.microsoft.com/.default"}
+	authType, fields := expandOAuth2(testOAuth2ResourceData(t, map[string]interface{}{
+		"grant_type":    "client_credentials",
+		"client_id":     "client",
+		"client_secret": "secret",
+		"scopes":        scopes,
+	}), testOAuth2CatalogApp())
+
+	oauth2 := flattenOAuth2(client.App{Type: authType, Fields: fields}, nil)
+	if len(oauth2) != 1 {
+		t.Fatalf("expected the OAuth2 settings, got %#v", oauth2)
+	}
+	if got := oauth2[0]["scopes"]; !reflect.DeepEqual(got, []string{"openid", "offline_access", "https://graph.microsoft.com/.default"}) {
+		t.Fatalf("expected the scopes %v, got %v", scopes, got)
+	}
+	if oauth2[0]["token_uri"] != "https://login.example.com/token" || oauth2[0]["grant_type"] != "client_credentials" {
+		t.Fatalf("expected the settings to be kept, got %#v", oauth2[0])
+	}
+}
+
+func TestValidateOAuth2(t *testing.T) {
+	apiKeyApp := testCatalogApp()
+	cases := []struct {
+		name              string
+		app               client.AppAuthentication
+		oauth2            map[string]interface{}
+		refreshTokenKnown bool
+		err               string
+	}{
+		{
+			name:              "client credentials",
+			app:               testOAuth2CatalogApp(),
+			oauth2:            map[string]interface{}{"grant_type": "client_credentials", "refresh_token": ""},
+			refreshTokenKnown: true,
+		},
+		{
+			name:              "refresh token",
+			app:               testOAuth2CatalogApp(),
+			oauth2:            map[string]interface{}{"grant_type": "refresh_token", "refresh_token": "token"},
+			refreshTokenKnown: true,
+		},
+		{
+			name:              "missing refresh token",
+			app:               testOAuth2CatalogApp(),
+			oauth2:            map[string]interface{}{"grant_type": "refresh_token", "refresh_token": ""},
+			refreshTokenKnown: true,
+			err:               "oauth2.refresh_token is required with the refresh_token grant type",
+		},
+		{
+			name:   "unknown refresh token",
+			app:    testOAuth2CatalogApp(),
+			oauth2: map[string]interface{}{"grant_type": "refresh_token", "refresh_token": ""},
+		},
+		{
+			name:              "app not using OAuth2",
+			app:               apiKeyApp,
+			oauth2:            map[string]interface{}{"grant_type": "client_credentials", "refresh_token": ""},
+			refreshTokenKnown: true,
+			err:               "The app http (1.0.0) doesn't use OAuth2 but api-key",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateOAuth2(tc.app, tc.oauth2, tc.refreshTokenKnown)
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+				t.Fatalf("expected the error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+func TestAppAuthenticationDeleteInUse(t *testing.T) {
+	s, c := newStubShuffle(t)
+	s.addAuth(client.App{Id: "auth1", Label: "auth"})
+	s.useInWorkflow("auth1", "w1")
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("auth1")
+
+	diags := r.DeleteContext(context.Background(), d, c)
+	if !diags.HasError() {
+		t.Fatalf("expected the delete to fail while a workflow uses the authentication")
+	}
+	// The refusal of Shuffle doesn't say the authentication is in use, its usage does
+	if !strings.Contains(diags[0].Summary, "still used by 1 workflow(s)") || !strings.Contains(diags[0].Detail, "force_delete") {
+		t.Fatalf("unexpected diagnostic %+v", diags[0])
+	}
+	if _, ok := s.getAuth("auth1"); !ok {
+		t.Fatalf("expected the authentication to be kept")
+	}
+}
+
+func TestAppAuthenticationForceDelete(t *testing.T) {
+	s, c := newStubShuffle(t)
+	s.addAuth(client.App{Id: "auth1", Label: "auth"})
+	s.useInWorkflow("auth1", "w1")
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("auth1")
+	d.Set("force_delete", true)
+
+	if diags := r.DeleteContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("unexpected error %+v", diags)
+	}
+	if _, ok := s.getAuth("auth1"); ok {
+		t.Fatalf("expected the authentication to be deleted")
+	}
+	if action := s.workflowActions("w1")[0].(map[string]interface{}); action["authentication_id"] != "" {
+		t.Fatalf("expected the authentication to be detached from the workflow, got %v", action)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the id to be cleared")
+	}
+}
+
+func TestAppAuthenticationDeleteFailure(t *testing.T) {
+	s, c := newStubShuffle(t)
+	s.addAuth(client.App{Id: "auth1", Label: "auth"})
+	s.deleteFailure = http.StatusBadRequest
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("auth1")
+	d.Set("force_delete", true)
+
+	diags := r.DeleteContext(context.Background(), d, c)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "Something went wrong") {
+		t.Fatalf("expected the error of Shuffle when the authentication isn't used, got %+v", diags)
+	}
+}
+
+func TestAppAuthenticationDeleteGone(t *testing.T) {
+	_, c := newStubShuffle(t)
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("auth1")
+
+	if diags := r.DeleteContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("expected a missing authentication to be considered deleted, got %+v", diags)
+	}
+}
+
+func testCatalogApp() client.AppAuthentication {
+	return client.AppAuthentication{
+		Name:       "http",
+		Id:         "http1",
+		AppVersion: "1.0.0",
+		LargeImage: "data:image/png;base64,AAAA",
+		Authentication: client.Authentication{
+			Type:     "api-key",
+			Required: true,
+			Parameters: []client.AuthenticationParameter{
+				{Name: "url", Required: true},
+				{Name: "apikey", Required: true},
+			},
+		},
+	}
+}
+
+const testAppAuthenticationConfig = `
+resource "shufflesoar_app_authentication" "test" {
+  label = "auth"
+  app {
+    name = "http"
+  }
+  fields = {
+    url    = "https://example.com"
+    apikey = "secret"
+  }
+}
+`
+
+func TestAppAuthenticationEmptyPlanAfterApply(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		check  resource.TestCheckFunc
+	}{
+		{
+			name:   "defaults",
+			config: testAppAuthenticationConfig,
+			check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "active", "true"),
+				resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.large_image", "data:image/png;base64,AAAA"),
+			),
+		},
+		{
+			name: "inactive with an image",
+			config: `
+resource "shufflesoar_app_authentication" "test" {
+  label  = "auth"
+  active = false
+  app {
+    name        = "http"
+    large_image = "data:image/png;base64,BBBB"
+  }
+  fields = {
+    apikey = "secret"
+    url    = "https://example.com"
+  }
+}
+`,
+			check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "active", "false"),
+				resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.large_image", "data:image/png;base64,BBBB"),
+			),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, c := newStubShuffle(t, testCatalogApp())
+
+			resource.UnitTest(t, resource.TestCase{
+				PreCheck:          func() { testPreCheckTerraform(t) },
+				ProviderFactories: testProviderFactories(c),
+				Steps: []resource.TestStep{
+					{
+						Config: tc.config,
+						Check: resource.ComposeTestCheckFunc(
+							tc.check,
+							// Shuffle masks the values and reorders the fields, the state keeps the configured ones
+							resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "fields.url", "https://example.com"),
+							resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "fields.apikey", "secret"),
+						),
+					},
+					{
+						Config:   tc.config,
+						PlanOnly: true,
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestAppAuthenticationApplySetsResolvedApp(t *testing.T) {
+	otherApp := testCatalogApp()
+	otherApp.Name = "http_v2"
+	otherApp.Id = "http2"
+	otherApp.AppVersion = "2.0.0"
+	otherApp.LargeImage = "data:image/png;base64,CCCC"
+	_, c := newStubShuffle(t, testCatalogApp(), otherApp)
+
+	checkApp := func(expected client.AppAuthentication) resource.TestCheckFunc {
+		return resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.id", expected.Id),
+			resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.name", expected.Name),
+			resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.app_version", expected.AppVersion),
+			resource.TestCheckResourceAttr("shufflesoar_app_authentication.test", "app.0.large_image", expected.LargeImage),
+		)
+	}
+
+	// The checks run on the state returned by the apply, before any refresh
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testPreCheckTerraform(t) },
+		ProviderFactories: testProviderFactories(c),
+		Steps: []resource.TestStep{
+			{
+				Config: testAppAuthenticationConfig,
+				Check:  checkApp(testCatalogApp()),
+			},
+			{
+				Config: strings.Replace(testAppAuthenticationConfig, `name = "http"`, `name = "http_v2"`, 1),
+				Check:  checkApp(otherApp),
+			},
+		},
+	})
+}
+
+func testImportedAuth() client.App {
+	return client.App{
+		Id:     "auth1",
+		Label:  "auth",
+		Active: true,
+		App:    client.AppAuthentication{Name: "http", Id: "http1", AppVersion: "1.0.0", LargeImage: "data:image/png;base64,AAAA"},
+		Fields: []client.Field{{Key: "url", Value: "https://example.com"}, {Key: "apikey", Value: "secret"}},
+	}
+}
+
+func TestAppAuthenticationImport(t *testing.T) {
+	cases := []struct {
+		importId string
+		err      string
+	}{
+		{importId: "auth1"},
+		{importId: "label:auth"},
+		{importId: "http/auth"},
+		{importId: "unknown", err: "App (unknown) not found"},
+		{importId: "label:shared", err: "2 apps found with label (shared)"},
+		{importId: "other/auth", err: "App with label (auth) not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.importId, func(t *testing.T) {
+			s, c := newStubShuffle(t, testCatalogApp())
+			s.addAuth(testImportedAuth())
+			s.addAuth(client.App{Id: "auth2", Label: "shared", App: client.AppAuthentication{Name: "http"}})
+			s.addAuth(client.App{Id: "auth3", Label: "shared", App: client.AppAuthentication{Name: "other"}})
+
+			r := ResourceAppAuthentication()
+			d := r.TestResourceData()
+			d.SetId(tc.importId)
+
+			imported, err := r.Importer.StateContext(context.Background(), d, c)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected the error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if len(imported) != 1 || imported[0].Id() != "auth1" {
+				t.Fatalf("expected auth1 to be imported, got %v", imported)
+			}
+		})
+	}
+}
+
+func TestAppAuthenticationEmptyPlanAfterImport(t *testing.T) {
+	s, c := newStubShuffle(t, testCatalogApp())
+	s.addAuth(testImportedAuth())
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("label:auth")
+	imported, err := r.Importer.StateContext(context.Background(), d, c)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if diags := r.ReadContext(context.Background(), imported[0], c); diags.HasError() {
+		t.Fatalf("unexpected error %+v", diags)
+	}
+
+	// Shuffle masks the values, which are kept masked in the state until the next apply
+	state := imported[0].State()
+	if state.Attributes["fields.url"] != client.MaskedFieldValue {
+		t.Fatalf("expected the imported values to be masked, got %v", state.Attributes)
+	}
+
+	config := map[string]interface{}{
+		"label":  "auth",
+		"app":    []interface{}{map[string]interface{}{"name": "http"}},
+		"fields": map[string]interface{}{"url": "https://example.com", "apikey": "secret"},
+	}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("expected an empty plan after the import, got %v", diff.Attributes)
+	}
+
+	config["active"] = false
+	if diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c); err != nil || diff.Empty() {
+		t.Fatalf("expected a change of the configuration to be planned, got %v %v", diff, err)
+	}
+}
+
+func TestAppAuthenticationImportSteps(t *testing.T) {
+	_, c := newStubShuffle(t, testCatalogApp())
+
+	importStep := func(importId string) resource.TestStep {
+		return resource.TestStep{
+			ResourceName:  "shufflesoar_app_authentication.test",
+			ImportState:   true,
+			ImportStateId: importId,
+			ImportStateCheck: func(states []*terraform.InstanceState) error {
+				if len(states) != 1 || states[0].ID != "auth1" {
+					return fmt.Errorf("expected auth1 to be imported, got %v", states)
+				}
+				if states[0].Attributes["fields.url"] != client.MaskedFieldValue {
+					return fmt.Errorf("expected the imported values to be masked, got %v", states[0].Attributes)
+				}
+				return nil
+			},
+		}
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testPreCheckTerraform(t) },
+		ProviderFactories: testProviderFactories(c),
+		Steps: []resource.TestStep{
+			{Config: testAppAuthenticationConfig},
+			importStep("auth1"),
+			importStep("label:auth"),
+			importStep("http/auth"),
+		},
+	})
+}
+
+func TestAppAuthenticationPlanChecksOAuth2Change(t *testing.T) {
+	s, c := newStubShuffle(t, testCatalogApp())
+	s.addAuth(testImportedAuth())
+
+	r := ResourceAppAuthentication()
+	d := r.TestResourceData()
+	d.SetId("auth1")
+	if diags := r.ReadContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("unexpected error %+v", diags)
+	}
+	// The state keeps the applied values of the fields, which Shuffle masks
+	d.Set("fields", map[string]interface{}{"url": "https://example.com", "apikey": "secret"})
+
+	// Only the OAuth2 settings change, the app using an API key instead
+	config := map[string]interface{}{
+		"label":  "auth",
+		"app":    []interface{}{map[string]interface{}{"name": "http"}},
+		"fields": map[string]interface{}{"url": "https://example.com", "apikey": "secret"},
+		"oauth2": []interface{}{map[string]interface{}{
+			"grant_type":    "client_credentials",
+			"client_id":     "client",
+			"client_secret": "secret",
+		}},
+	}
+	_, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(config), c)
+	if err == nil || !strings.Contains(err.Error(), "doesn't use OAuth2") {
+		t.Fatalf("expected the OAuth2 settings to be checked, got %v", err)
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	app := testCatalogApp()
+	app.Authentication.Parameters = append(app.Authentication.Parameters, client.AuthenticationParameter{Name: "certificate", Multiline: true})
+
+	cases := []struct {
+		name              string
+		fields            map[string]interface{}
+		requireParameters bool
+		errors            []string
+	}{
+		{
+			name:              "valid",
+			fields:            map[string]interface{}{"url": "https://example.com", "apikey": "secret", "certificate": "line1\nline2"},
+			requireParameters: true,
+		},
+		{
+			name:              "unknown key",
+			fields:            map[string]interface{}{"url": "https://example.com", "apikey": "secret", "token": "secret"},
+			requireParameters: true,
+			errors:            []string{"the field (token) is not an authentication parameter of the app, expected one of: url, apikey, certificate"},
+		},
+		{
+			name:              "missing required parameters",
+			fields:            map[string]interface{}{"certificate": "cert"},
+			requireParameters: true,
+			errors:            []string{"the required field (url) is missing", "the required field (apikey) is missing"},
+		},
+		{
+			name:              "parameters not required",
+			fields:            map[string]interface{}{},
+			requireParameters: false,
+		},
+		{
+			name:              "multiline value",
+			fields:            map[string]interface{}{"url": "https://example.com", "apikey": "line1\nline2"},
+			requireParameters: true,
+			errors:            []string{"the field (apikey) can't span multiple lines"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFields(app, tc.fields, tc.requireParameters)
+			if len(tc.errors) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected the errors %v", tc.errors)
+			}
+			expected := "Invalid fields for the app http (1.0.0):\n- " + strings.Join(tc.errors, "\n- ")
+			if err.Error() != expected {
+				t.Fatalf("expected the error %q, got %q", expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
+)
+
+func ResourceWorkflow() *schema.Resource {
+	r := &schema.Resource{
+		CreateContext: resourceWorkflowCreate,
+		ReadContext:   resourceWorkflowRead,
+		UpdateContext: resourceWorkflowUpdate,
+		DeleteContext: resourceWorkflowDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: client.GetDefaultWorkflowSchema().Schema,
+	}
+
+	r.Schema["definition"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  "The JSON definition of the workflow graph (`start`, `actions`, `triggers` and `branches`), i.e an export from the Shuffle UI. Keys not managed by this provider are ignored, Shuffle keeps the values it stores for them. Conflicts with the `start`, `actions`, `triggers` and `branches` blocks.",
+		ValidateFunc: validation.StringIsJSON,
+		StateFunc:    normalizeWorkflowDefinitionJson,
+	}
+
+	r = utils.RecurseSetSchemaStatus(r, utils.Optional, true)
+
+	r = utils.RecurseSetSchemaStatusByKey(r, "id", utils.Computed, true)
+	r = utils.RecurseSetSchemaStatusByKey(r, "org_id", utils.Computed, true)
+	r = utils.RecurseSetSchemaStatusByKey(r, "name", utils.Required, true)
+	r = utils.RecurseSetSchemaStatusByKey(r, "actions.id", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "actions.app_name", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "actions.name", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "triggers.id", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "triggers.trigger_type", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "branches.id", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "branches.source_id", utils.Required, false)
+	r = utils.RecurseSetSchemaStatusByKey(r, "branches.destination_id", utils.Required, false)
+
+	r.Schema["definition"].ConflictsWith = []string{"start", "actions", "triggers", "branches"}
+
+	actionSchema := r.Schema["actions"].Elem.(*schema.Resource).Schema
+	triggerSchema := r.Schema["triggers"].Elem.(*schema.Resource).Schema
+	actionSchema["position"].MaxItems = 1
+	triggerSchema["position"].MaxItems = 1
+	// Shuffle fills these in when it saves the workflow
+	actionSchema["environment"].Computed = true
+	actionSchema["app_id"].Computed = true
+	actionSchema["app_version"].Computed = true
+	triggerSchema["status"].Computed = true
+	triggerSchema["environment"].Computed = true
+	triggerSchema["name"].Computed = true
+	conditionSchema := r.Schema["branches"].Elem.(*schema.Resource).Schema["conditions"].Elem.(*schema.Resource).Schema
+	conditionSchema["source"].MaxItems = 1
+	conditionSchema["condition"].MaxItems = 1
+	conditionSchema["destination"].MaxItems = 1
+
+	return r
+}
+
+// normalizeWorkflowDefinition makes sure two definitions describing the same
+// graph are identical once marshalled, whether they come from the user or from Shuffle
+func normalizeWorkflowDefinition(definition client.WorkflowDefinition) client.WorkflowDefinition {
+	if definition.Actions == nil {
+		definition.Actions = []client.WorkflowAction{}
+	}
+	if definition.Triggers == nil {
+		definition.Triggers = []client.WorkflowTrigger{}
+	}
+	if definition.Branches == nil {
+		definition.Branches = []client.WorkflowBranch{}
+	}
+
+	for i := range definition.Actions {
+		definition.Actions[i].IsStartNode = definition.Actions[i].Id == definition.Start
+		if definition.Actions[i].Parameters == nil {
+			definition.Actions[i].Parameters = []client.WorkflowParameter{}
+		}
+	}
+	for i := range definition.Triggers {
+		if definition.Triggers[i].Parameters == nil {
+			definition.Triggers[i].Parameters = []client.WorkflowParameter{}
+		}
+	}
+	for i := range definition.Branches {
+		if definition.Branches[i].Conditions == nil {
+			definition.Branches[i].Conditions = []client.WorkflowBranchCondition{}
+		}
+	}
+
+	return definition
+}
+
+func workflowDefinitionToJson(definition client.WorkflowDefinition) (string, error) {
+	jsonData, err := json.Marshal(normalizeWorkflowDefinition(definition))
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
+
+func workflowDefinitionFromJson(definitionJson string) (client.WorkflowDefinition, error) {
+	var definition client.WorkflowDefinition
+	if err := json.Unmarshal([]byte(definitionJson), &definition); err != nil {
+		return client.WorkflowDefinition{}, err
+	}
+	return normalizeWorkflowDefinition(definition), nil
+}
+
+func normalizeWorkflowDefinitionJson(v interface{}) string {
+	definition, err := workflowDefinitionFromJson(v.(string))
+	if err != nil {
+		return v.(string)
+	}
+	definitionJson, err := workflowDefinitionToJson(definition)
+	if err != nil {
+		return v.(string)
+	}
+	return definitionJson
+}
+
+func expandWorkflowDefinition(d *schema.ResourceData) (client.WorkflowDefinition, error) {
+	if definitionJson, ok := d.GetOk("definition"); ok {
+		return workflowDefinitionFromJson(definitionJson.(string))
+	}
+
+	var definition client.WorkflowDefinition
+	if err := utils.ExpandResourceData(d, client.GetDefaultWorkflowSchema().Schema, &definition); err != nil {
+		return client.WorkflowDefinition{}, err
+	}
+
+	return normalizeWorkflowDefinition(definition), nil
+}
+
+func createWorkflowObj(d *schema.ResourceData) (client.Workflow, error) {
+	var workflow client.Workflow
+	if err := utils.ExpandResourceData(d, client.GetDefaultWorkflowSchema().Schema, &workflow); err != nil {
+		return client.Workflow{}, err
+	}
+
+	definition, err := expandWorkflowDefinition(d)
+	if err != nil {
+		return client.Workflow{}, err
+	}
+
+	workflow.Id = d.Id()
+	workflow.WorkflowDefinition = definition
+	if workflow.Tags == nil {
+		workflow.Tags = []string{}
+	}
+
+	return workflow, nil
+}
+
+func setWorkflowDefinition(d *schema.ResourceData, definition client.WorkflowDefinition) error {
+	// Shuffle places the nodes at the origin when no position is given, which
+	// flattens to no position block as any empty struct
+	return utils.FlattenToResourceData(d, client.GetDefaultWorkflowSchema().Schema, definition)
+}
+
+func resourceWorkflowCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.ShuffleClient)
+
+	workflow, err := createWorkflowObj(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := c.CreateWorkflow(ctx, workflow)
+	if id != "" {
+		// Keep the workflow in the state even if saving its graph failed so it is not orphaned
+		d.SetId(id)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceWorkflowRead(ctx, d, m)
+}
+
+func resourceWorkflowRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	id := d.Id()
+
+	c := m.(*client.ShuffleClient)
+
+	workflow, err := c.GetWorkflow(ctx, id)
+	if client.IsNotFound(err) {
+		log.Printf("[WARN] Workflow (%s) not found, removing from state", id)
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("name", workflow.Name)
+	d.Set("description", workflow.Description)
+	d.Set("tags", workflow.Tags)
+	d.Set("org_id", workflow.OrgId)
+
+	if _, ok := d.GetOk("definition"); ok {
+		definitionJson, err := workflowDefinitionToJson(workflow.WorkflowDefinition)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("definition", definitionJson)
+	} else if err := setWorkflowDefinition(d, normalizeWorkflowDefinition(workflow.WorkflowDefinition)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceWorkflowUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.ShuffleClient)
+
+	workflow, err := createWorkflowObj(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := c.UpdateWorkflow(ctx, workflow); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceWorkflowRead(ctx, d, m)
+}
+
+func resourceWorkflowDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*client.ShuffleClient)
+
+	if err := c.DeleteWorkflow(ctx, d.Id()); err != nil && !client.IsNotFound(err) {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,255 @@
+package resources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+func testWorkflowConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        "wf",
+		"description": "A workflow",
+		"tags":        []interface{}{"a", "b"},
+		"start":       "a1",
+		"actions": []interface{}{
+			map[string]interface{}{
+				"id":       "a1",
+				"app_name": "Shuffle Tools",
+				"name":     "repeat_back_to_me",
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "call", "value": "$exec.field"},
+				},
+				"position": []interface{}{
+					map[string]interface{}{"x": 1.5, "y": 2.0},
+				},
+			},
+			map[string]interface{}{
+				"id":       "a2",
+				"app_name": "Shuffle Tools",
+				"name":     "repeat_back_to_me",
+			},
+		},
+		"triggers": []interface{}{
+			map[string]interface{}{
+				"id":           "t1",
+				"app_name":     "Webhook",
+				"trigger_type": "WEBHOOK",
+			},
+		},
+		"branches": []interface{}{
+			map[string]interface{}{
+				"id":             "b1",
+				"source_id":      "a1",
+				"destination_id": "a2",
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"source":      []interface{}{map[string]interface{}{"name": "source", "value": "$a1"}},
+						"condition":   []interface{}{map[string]interface{}{"name": "condition", "value": "equals"}},
+						"destination": []interface{}{map[string]interface{}{"name": "destination", "value": "ok"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCreateWorkflowObj(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ResourceWorkflow().Schema, testWorkflowConfig())
+	d.SetId("w1")
+
+	workflow, err := createWorkflowObj(d)
+	if err != nil {
+		t.Fatalf("createWorkflowObj: %s", err)
+	}
+
+	if workflow.Id != "w1" || workflow.Name != "wf" || workflow.Description != "A workflow" || !reflect.DeepEqual(workflow.Tags, []string{"a", "b"}) {
+		t.Fatalf("unexpected workflow attributes %+v", workflow)
+	}
+	if len(workflow.Actions) != 2 || len(workflow.Triggers) != 1 || len(workflow.Branches) != 1 {
+		t.Fatalf("unexpected graph %+v", workflow.WorkflowDefinition)
+	}
+
+	a1, a2 := workflow.Actions[0], workflow.Actions[1]
+	if !a1.IsStartNode || a2.IsStartNode {
+		t.Fatalf("expected only the start action to be the start node, got %t and %t", a1.IsStartNode, a2.IsStartNode)
+	}
+	if !reflect.DeepEqual(a1.Parameters, []client.WorkflowParameter{{Name: "call", Value: "$exec.field"}}) {
+		t.Fatalf("unexpected parameters %+v", a1.Parameters)
+	}
+	if a1.Position != (client.WorkflowPosition{X: 1.5, Y: 2}) || a2.Position != (client.WorkflowPosition{}) {
+		t.Fatalf("unexpected positions %+v and %+v", a1.Position, a2.Position)
+	}
+	if a2.Parameters == nil {
+		t.Fatalf("expected the actions without parameters to send an empty list")
+	}
+
+	condition := workflow.Branches[0].Conditions[0]
+	if condition.Source.Value != "$a1" || condition.Condition.Value != "equals" || condition.Destination.Value != "ok" {
+		t.Fatalf("unexpected condition %+v", condition)
+	}
+}
+
+func TestWorkflowDefinitionRoundTrip(t *testing.T) {
+	r := ResourceWorkflow()
+	d := schema.TestResourceDataRaw(t, r.Schema, testWorkflowConfig())
+	workflow, err := createWorkflowObj(d)
+	if err != nil {
+		t.Fatalf("createWorkflowObj: %s", err)
+	}
+
+	read := r.TestResourceData()
+	if err := setWorkflowDefinition(read, workflow.WorkflowDefinition); err != nil {
+		t.Fatalf("setWorkflowDefinition: %s", err)
+	}
+	read.Set("name", workflow.Name)
+	read.Set("description", workflow.Description)
+	read.Set("tags", workflow.Tags)
+
+	// The nodes without position and the empty conditions must not show up in the state
+	if _, ok := read.GetOk("actions.1.position.0"); ok {
+		t.Fatalf("expected no position block for a node at the origin")
+	}
+
+	readWorkflow, err := createWorkflowObj(read)
+	if err != nil {
+		t.Fatalf("createWorkflowObj: %s", err)
+	}
+	if !reflect.DeepEqual(workflow, readWorkflow) {
+		t.Fatalf("the workflow changed once read back:\n%+v\n%+v", workflow, readWorkflow)
+	}
+}
+
+func TestWorkflowDefinitionJson(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, ResourceWorkflow().Schema, map[string]interface{}{
+		"name":       "wf",
+		"definition": `{"start": "a1", "actions": [{"id": "a1", "app_name": "Shuffle Tools", "name": "repeat_back_to_me", "unmanaged": true}]}`,
+	})
+
+	workflow, err := createWorkflowObj(d)
+	if err != nil {
+		t.Fatalf("createWorkflowObj: %s", err)
+	}
+	if workflow.Start != "a1" || len(workflow.Actions) != 1 || !workflow.Actions[0].IsStartNode {
+		t.Fatalf("unexpected graph %+v", workflow.WorkflowDefinition)
+	}
+
+	// The definitions from the user and from Shuffle must match once normalized
+	fromUser := normalizeWorkflowDefinitionJson(`{"start": "a1", "actions": [{"id": "a1", "name": "repeat_back_to_me"}]}`)
+	fromShuffle, err := workflowDefinitionToJson(client.WorkflowDefinition{
+		Start:   "a1",
+		Actions: []client.WorkflowAction{{Id: "a1", Name: "repeat_back_to_me", IsStartNode: true}},
+	})
+	if err != nil {
+		t.Fatalf("workflowDefinitionToJson: %s", err)
+	}
+	if fromUser != fromShuffle {
+		t.Fatalf("expected the normalized definitions to match:\n%s\n%s", fromUser, fromShuffle)
+	}
+
+	if invalid := normalizeWorkflowDefinitionJson("not json"); invalid != "not json" {
+		t.Fatalf("expected an invalid definition to be kept as is, got %s", invalid)
+	}
+}
+
+func TestWorkflowCreateReadsFilledAttributes(t *testing.T) {
+	s, c := newStubShuffle(t)
+	r := ResourceWorkflow()
+	d := schema.TestResourceDataRaw(t, r.Schema, testWorkflowConfig())
+
+	if diags := r.CreateContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("unexpected error %+v", diags)
+	}
+	if _, ok := s.getWorkflow(d.Id()); !ok {
+		t.Fatalf("expected the workflow (%s) to be created", d.Id())
+	}
+
+	// The attributes Shuffle fills in are computed, so they don't show up as changes
+	cases := []struct {
+		block    string
+		attr     string
+		expected string
+	}{
+		{"actions", "environment", "Shuffle"},
+		{"actions", "app_id", "Shuffle Tools-id"},
+		{"actions", "app_version", "1.0.0"},
+		{"triggers", "status", "uninitialized"},
+		{"triggers", "environment", "Shuffle"},
+		{"triggers", "name", "Webhook"},
+	}
+	for _, tc := range cases {
+		key := tc.block + ".0." + tc.attr
+		if got := d.Get(key); got != tc.expected {
+			t.Errorf("expected %s to be %s, got %v", key, tc.expected, got)
+		}
+		if attrSchema := r.Schema[tc.block].Elem.(*schema.Resource).Schema[tc.attr]; !attrSchema.Optional || !attrSchema.Computed {
+			t.Errorf("expected %s to be optional and computed", key)
+		}
+	}
+}
+
+func TestWorkflowDeleteGone(t *testing.T) {
+	_, c := newStubShuffle(t)
+
+	r := ResourceWorkflow()
+	d := r.TestResourceData()
+	d.SetId("w1")
+
+	if diags := r.DeleteContext(context.Background(), d, c); diags.HasError() {
+		t.Fatalf("expected a missing workflow to be considered deleted, got %+v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected the id to be cleared")
+	}
+}
+
+func TestWorkflowEmptyPlanAfterApply(t *testing.T) {
+	_, c := newStubShuffle(t)
+	config := `
+resource "shufflesoar_workflow" "test" {
+  name  = "wf"
+  start = "a1"
+  actions {
+    id       = "a1"
+    app_name = "Shuffle Tools"
+    name     = "repeat_back_to_me"
+    parameters {
+      name  = "call"
+      value = "$exec.field"
+    }
+  }
+  triggers {
+    id           = "t1"
+    app_name     = "Webhook"
+    trigger_type = "WEBHOOK"
+  }
+  branches {
+    id             = "b1"
+    source_id      = "t1"
+    destination_id = "a1"
+  }
+}
+`
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testPreCheckTerraform(t) },
+		ProviderFactories: testProviderFactories(c),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("shufflesoar_workflow.test", "actions.0.environment", "Shuffle"),
+					resource.TestCheckResourceAttr("shufflesoar_workflow.test", "triggers.0.status", "uninitialized"),
+				),
+			},
+			{
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
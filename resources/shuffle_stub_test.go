@@ -0,0 +1,258 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+)
+
+// stubShuffle is an in-memory Shuffle serving the app authentications, the app
+// catalog and the workflows, masking the field values as Shuffle does
+type stubShuffle struct {
+	mu        sync.Mutex
+	auths     map[string]client.App
+	apps      []client.AppAuthentication
+	workflows map[string]map[string]interface{}
+	nextId    int
+	// refusal is the reason given when deleting an authentication still in use
+	refusal string
+	// deleteFailure makes the deletes fail with this status code when set
+	deleteFailure int
+}
+
+func newStubShuffle(t *testing.T, apps ...client.AppAuthentication) (*stubShuffle, *client.ShuffleClient) {
+	s := &stubShuffle{
+		auths:     map[string]client.App{},
+		apps:      apps,
+		workflows: map[string]map[string]interface{}{},
+		refusal:   "Authentication is assigned to workflows",
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	c, err := client.NewShuffleClient(server.URL, "test-token")
+	if err != nil {
+		t.Fatalf("NewShuffleClient: %s", err)
+	}
+	c.MaxRetries = 0
+	return s, c
+}
+
+// testProviderFactories returns the provider for resource.UnitTest, configured to use
+// the client of the stub
+func testProviderFactories(c *client.ShuffleClient) map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"shufflesoar": func() (*schema.Provider, error) {
+			return &schema.Provider{
+				ResourcesMap: map[string]*schema.Resource{
+					"shufflesoar_app_authentication": ResourceAppAuthentication(),
+					"shufflesoar_workflow":           ResourceWorkflow(),
+				},
+				ConfigureContextFunc: func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+					return c, nil
+				},
+			}, nil
+		},
+	}
+}
+
+// testPreCheckTerraform skips the tests run with resource.UnitTest when there is no
+// Terraform CLI to run them with, as the SDK would exit instead of failing the test
+func testPreCheckTerraform(t *testing.T) {
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" || os.Getenv("TF_ACC_TERRAFORM_VERSION") != "" {
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("the Terraform CLI isn't installed, set TF_ACC_TERRAFORM_PATH or TF_ACC_TERRAFORM_VERSION to run this test")
+	}
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *stubShuffle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	route := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+	authPrefix := "/api/v1/apps/authentication/"
+	workflowPrefix := "/api/v1/workflows/"
+
+	switch {
+	case route == "GET /api/v1/apps":
+		writeJson(w, http.StatusOK, s.apps)
+
+	case route == "GET /api/v1/apps/authentication":
+		auths := []client.App{}
+		for _, app := range s.auths {
+			// Shuffle doesn't keep the order of the fields
+			fields := []client.Field{}
+			for i := len(app.Fields) - 1; i >= 0; i-- {
+				fields = append(fields, client.Field{Key: app.Fields[i].Key, Value: client.MaskedFieldValue})
+			}
+			app.Fields = fields
+			auths = append(auths, app)
+		}
+		writeJson(w, http.StatusOK, map[string]interface{}{"success": true, "data": auths})
+
+	case route == "PUT /api/v1/apps/authentication":
+		var app client.App
+		if err := json.Unmarshal(body, &app); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string]interface{}{"success": false, "reason": err.Error()})
+			return
+		}
+		if app.Id == "" {
+			s.nextId++
+			app.Id = fmt.Sprintf("auth%d", s.nextId)
+		} else if current, ok := s.auths[app.Id]; ok {
+			app.Usage = current.Usage
+			app.WorkflowCount = current.WorkflowCount
+		}
+		// Shuffle keeps its own copy of the fields
+		app.Fields = append([]client.Field{}, app.Fields...)
+		s.auths[app.Id] = app
+		writeJson(w, http.StatusOK, map[string]interface{}{"success": true, "id": app.Id})
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, authPrefix):
+		id := strings.TrimPrefix(r.URL.Path, authPrefix)
+		app, ok := s.auths[id]
+		if !ok {
+			writeJson(w, http.StatusNotFound, map[string]interface{}{"success": false})
+			return
+		}
+		if s.deleteFailure != 0 {
+			writeJson(w, s.deleteFailure, map[string]interface{}{"success": false, "reason": "Something went wrong"})
+			return
+		}
+		for _, usage := range app.Usage {
+			for _, a := range s.workflowActions(usage.WorkfflowId) {
+				if a.(map[string]interface{})["authentication_id"] == id {
+					writeJson(w, http.StatusBadRequest, map[string]interface{}{"success": false, "reason": s.refusal})
+					return
+				}
+			}
+		}
+		delete(s.auths, id)
+		writeJson(w, http.StatusOK, map[string]interface{}{"success": true})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, workflowPrefix):
+		workflow, ok := s.workflows[strings.TrimPrefix(r.URL.Path, workflowPrefix)]
+		if !ok {
+			writeJson(w, http.StatusNotFound, map[string]interface{}{"success": false})
+			return
+		}
+		writeJson(w, http.StatusOK, workflow)
+
+	case route == "POST /api/v1/workflows":
+		var workflow map[string]interface{}
+		json.Unmarshal(body, &workflow)
+		s.nextId++
+		workflow["id"] = fmt.Sprintf("workflow%d", s.nextId)
+		s.workflows[workflow["id"].(string)] = workflow
+		writeJson(w, http.StatusOK, workflow)
+
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, workflowPrefix):
+		var workflow map[string]interface{}
+		json.Unmarshal(body, &workflow)
+		fillWorkflowNodes(workflow)
+		s.workflows[strings.TrimPrefix(r.URL.Path, workflowPrefix)] = workflow
+		writeJson(w, http.StatusOK, map[string]interface{}{"success": true})
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, workflowPrefix):
+		id := strings.TrimPrefix(r.URL.Path, workflowPrefix)
+		if _, ok := s.workflows[id]; !ok {
+			writeJson(w, http.StatusNotFound, map[string]interface{}{"success": false})
+			return
+		}
+		delete(s.workflows, id)
+		writeJson(w, http.StatusOK, map[string]interface{}{"success": true})
+
+	default:
+		writeJson(w, http.StatusNotFound, map[string]interface{}{"success": false, "reason": "no route for " + route})
+	}
+}
+
+// fillWorkflowNodes sets the attributes of the nodes Shuffle fills in when the
+// workflow is saved
+func fillWorkflowNodes(workflow map[string]interface{}) {
+	setDefault := func(node map[string]interface{}, key string, value interface{}) {
+		if current, _ := node[key].(string); current == "" {
+			node[key] = value
+		}
+	}
+	actions, _ := workflow["actions"].([]interface{})
+	for _, a := range actions {
+		action := a.(map[string]interface{})
+		setDefault(action, "environment", "Shuffle")
+		setDefault(action, "app_id", fmt.Sprintf("%s-id", action["app_name"]))
+		setDefault(action, "app_version", "1.0.0")
+	}
+	triggers, _ := workflow["triggers"].([]interface{})
+	for _, t := range triggers {
+		trigger := t.(map[string]interface{})
+		setDefault(trigger, "status", "uninitialized")
+		setDefault(trigger, "environment", "Shuffle")
+		setDefault(trigger, "name", trigger["app_name"])
+	}
+}
+
+// getWorkflow returns the workflow as stored in Shuffle
+func (s *stubShuffle) getWorkflow(id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workflow, ok := s.workflows[id]
+	return workflow, ok
+}
+
+func (s *stubShuffle) workflowActions(id string) []interface{} {
+	actions, _ := s.workflows[id]["actions"].([]interface{})
+	return actions
+}
+
+// useInWorkflow makes a workflow action use the app authentication
+func (s *stubShuffle) useInWorkflow(authId string, workflowId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workflows[workflowId] = map[string]interface{}{
+		"id":      workflowId,
+		"actions": []interface{}{map[string]interface{}{"id": "a1", "authentication_id": authId}},
+	}
+	app := s.auths[authId]
+	app.Usage = append(app.Usage, client.AppUsage{WorkfflowId: workflowId, Nodes: []string{"a1"}})
+	app.WorkflowCount = len(app.Usage)
+	s.auths[authId] = app
+}
+
+// addAuth stores an app authentication as if it had been created in Shuffle
+func (s *stubShuffle) addAuth(app client.App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auths[app.Id] = app
+}
+
+func (s *stubShuffle) getAuth(id string) (client.App, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.auths[id]
+	return app, ok
+}
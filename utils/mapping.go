@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Flatten converts a struct into the map of its attributes, named and shaped as in the
+// schemas built by SchemaFromStruct: nested structs become lists of one block (none when
+// the struct is empty), nil pointers are left out and interface{} values become JSON strings
+func Flatten(v interface{}) map[string]interface{} {
+	return flattenStruct(reflect.Indirect(reflect.ValueOf(v)))
+}
+
+// FlattenToResourceData sets the attributes of the struct which are in the schema. The id
+// is left out, as it is set with SetId.
+func FlattenToResourceData(d *schema.ResourceData, s map[string]*schema.Schema, v interface{}) error {
+	for key, value := range Flatten(v) {
+		if _, ok := s[key]; !ok || key == "id" {
+			continue
+		}
+		if err := d.Set(key, value); err != nil {
+			return fmt.Errorf("Failed to set %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// Expand fills the struct pointed by out from a map of attributes, as returned by
+// ResourceData.Get for a nested block
+func Expand(m map[string]interface{}, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Expand needs a pointer to a struct, got %T", out)
+	}
+	return expandStruct(m, v.Elem())
+}
+
+// ExpandResourceData fills the struct pointed by out from the attributes of the
+// ResourceData which are in the schema
+func ExpandResourceData(d *schema.ResourceData, s map[string]*schema.Schema, out interface{}) error {
+	m := make(map[string]interface{}, len(s))
+	for key := range s {
+		m[key] = d.Get(key)
+	}
+	return Expand(m, out)
+}
+
+func isIgnored(f reflect.StructField) bool {
+	return f.PkgPath != "" || strings.Split(f.Tag.Get("tf"), ",")[0] == "-"
+}
+
+func flattenStruct(v reflect.Value) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for key, value := range flattenStruct(v.Field(i)) {
+				m[key] = value
+			}
+			continue
+		}
+		if isIgnored(f) {
+			continue
+		}
+
+		if value, ok := flattenValue(v.Field(i)); ok {
+			m[AttributeName(f)] = value
+		}
+	}
+	return m
+}
+
+func flattenValue(v reflect.Value) (interface{}, bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, false
+		}
+		return flattenValue(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return "", true
+		}
+		jsonData, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, false
+		}
+		return string(jsonData), true
+	case reflect.Struct:
+		if v.IsZero() {
+			return []interface{}{}, true
+		}
+		return []interface{}{flattenStruct(v)}, true
+	case reflect.Slice:
+		l := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct {
+				l = append(l, flattenStruct(elem))
+			} else if value, ok := flattenValue(elem); ok {
+				l = append(l, value)
+			}
+		}
+		return l, true
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			if value, ok := flattenValue(v.MapIndex(key)); ok {
+				m[key.String()] = value
+			}
+		}
+		return m, true
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return v.Bool(), true
+	}
+	return nil, false
+}
+
+func expandStruct(m map[string]interface{}, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := expandStruct(m, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if isIgnored(f) {
+			continue
+		}
+
+		name := AttributeName(f)
+		if err := expandValue(m[name], v.Field(i)); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+func expandValue(raw interface{}, v reflect.Value) error {
+	if set, ok := raw.(*schema.Set); ok {
+		raw = set.List()
+	}
+	if raw == nil {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		// A pointer to a struct is nil when its block isn't set, the other values are
+		// kept even when they are the zero value
+		if l, ok := raw.([]interface{}); ok && v.Type().Elem().Kind() == reflect.Struct && (len(l) == 0 || l[0] == nil) {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := expandValue(raw, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Interface:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("expected a JSON string, got %T", raw)
+		}
+		if s == "" {
+			return nil
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(s), &value); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&value).Elem())
+		return nil
+	case reflect.Struct:
+		// A nested block is a list of one element
+		if l, ok := raw.([]interface{}); ok {
+			if len(l) == 0 || l[0] == nil {
+				return nil
+			}
+			raw = l[0]
+		}
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a block, got %T", raw)
+		}
+		return expandStruct(m, v)
+	case reflect.Slice:
+		l, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected a list, got %T", raw)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(l), len(l))
+		for i, elem := range l {
+			if err := expandValue(elem, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a map, got %T", raw)
+		}
+		result := reflect.MakeMapWithSize(v.Type(), len(m))
+		for key, elem := range m {
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := expandValue(elem, value); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), value)
+		}
+		v.Set(result)
+		return nil
+	}
+
+	value := reflect.ValueOf(raw)
+	if !value.Type().ConvertibleTo(v.Type()) || (value.Kind() == reflect.String) != (v.Kind() == reflect.String) {
+		return fmt.Errorf("can't expand %T into %s", raw, v.Type())
+	}
+	v.Set(value.Convert(v.Type()))
+	return nil
+}
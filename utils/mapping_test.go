@@ -0,0 +1,203 @@
+package utils_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/client"
+	"github.com/tristandostaler/terraform-provider-shufflesoar/utils"
+)
+
+type testPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type testNode struct {
+	Name     string       `json:"name"`
+	Count    int          `json:"count"`
+	Enabled  bool         `json:"enabled"`
+	Position testPosition `json:"position"`
+}
+
+type testCommon struct {
+	Label string `json:"label"`
+}
+
+type testEntity struct {
+	Name      string                 `json:"name"`
+	Tags      []string               `json:"tags"`
+	Labels    map[string]string      `json:"labels"`
+	Nodes     []testNode             `json:"nodes"`
+	NodePtrs  []*testNode            `json:"node_ptrs"`
+	Main      testNode               `json:"main"`
+	Other     *testNode              `json:"other"`
+	Missing   *testNode              `json:"missing"`
+	Enabled   *bool                  `json:"enabled"`
+	Retries   *int                   `json:"retries"`
+	Value     interface{}            `json:"value"`
+	NoValue   interface{}            `json:"no_value"`
+	Internal  string                 `json:"internal" tf:"-"`
+	Extra     map[string]interface{} `json:"extra"`
+	unexposed string
+	testCommon
+}
+
+func testEntityValue() testEntity {
+	enabled := false
+	retries := 0
+	return testEntity{
+		Name:   "entity",
+		Tags:   []string{"a", "b"},
+		Labels: map[string]string{"env": "test"},
+		Nodes: []testNode{
+			{Name: "first", Count: 1, Enabled: true, Position: testPosition{X: 1.5, Y: 2}},
+			{Name: "second"},
+		},
+		NodePtrs: []*testNode{{Name: "pointed", Count: 2}},
+		Main:     testNode{Name: "main", Position: testPosition{X: 3}},
+		Other:    &testNode{Name: "other", Enabled: true},
+		Enabled:  &enabled,
+		Retries:  &retries,
+		Value:    map[string]interface{}{"key": "value", "list": []interface{}{1.0, "two"}},
+		Extra:    map[string]interface{}{"one": "1"},
+		testCommon: testCommon{
+			Label: "common",
+		},
+	}
+}
+
+// roundTrip sets the struct in a ResourceData of its schema and reads it back into out
+func roundTrip(t *testing.T, s map[string]*schema.Schema, v interface{}, out interface{}) {
+	t.Helper()
+	d := schema.TestResourceDataRaw(t, s, map[string]interface{}{})
+	if err := utils.FlattenToResourceData(d, s, v); err != nil {
+		t.Fatalf("FlattenToResourceData: %s", err)
+	}
+	if err := utils.ExpandResourceData(d, s, out); err != nil {
+		t.Fatalf("ExpandResourceData: %s", err)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	m := utils.Flatten(testEntityValue())
+
+	expectedNodePtrs := []interface{}{
+		map[string]interface{}{"name": "pointed", "count": 2, "enabled": false, "position": []interface{}{}},
+	}
+	if !reflect.DeepEqual(m["node_ptrs"], expectedNodePtrs) {
+		t.Fatalf("expected the pointed blocks %#v, got %#v", expectedNodePtrs, m["node_ptrs"])
+	}
+	if !reflect.DeepEqual(m["main"], []interface{}{map[string]interface{}{"name": "main", "count": 0, "enabled": false, "position": []interface{}{map[string]interface{}{"x": 3.0, "y": 0.0}}}}) {
+		t.Fatalf("expected the nested block as a list of one block, got %#v", m["main"])
+	}
+	if m["enabled"] != false || m["retries"] != 0 {
+		t.Fatalf("expected the zero values of the pointers, got %#v and %#v", m["enabled"], m["retries"])
+	}
+	if m["value"] != `{"key":"value","list":[1,"two"]}` || m["no_value"] != "" {
+		t.Fatalf("expected the interface{} values as JSON, got %#v and %#v", m["value"], m["no_value"])
+	}
+	if m["label"] != "common" {
+		t.Fatalf("expected the embedded struct to be flattened, got %#v", m)
+	}
+	for _, key := range []string{"missing", "internal", "unexposed", "testcommon"} {
+		if _, ok := m[key]; ok {
+			t.Fatalf("expected %s to be left out, got %#v", key, m[key])
+		}
+	}
+}
+
+func TestFlattenSkipsNilPointers(t *testing.T) {
+	m := utils.Flatten(testEntity{NodePtrs: []*testNode{nil, {Name: "pointed"}}})
+	if nodes := m["node_ptrs"].([]interface{}); len(nodes) != 1 || nodes[0].(map[string]interface{})["name"] != "pointed" {
+		t.Fatalf("expected only the pointed block, got %#v", nodes)
+	}
+}
+
+func TestExpandPointers(t *testing.T) {
+	var out testEntity
+	err := utils.Expand(map[string]interface{}{
+		"enabled": false,
+		"retries": 0,
+		"other":   []interface{}{map[string]interface{}{"name": ""}},
+		"missing": []interface{}{},
+	}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if out.Enabled == nil || *out.Enabled || out.Retries == nil || *out.Retries != 0 {
+		t.Fatalf("expected the zero values to be kept, got %v and %v", out.Enabled, out.Retries)
+	}
+	if out.Other == nil || out.Missing != nil {
+		t.Fatalf("expected only the set block to be expanded, got %v and %v", out.Other, out.Missing)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	var out testEntity
+	if err := utils.Expand(map[string]interface{}{"value": "{"}, &out); err == nil {
+		t.Fatalf("expected an error for invalid JSON")
+	}
+	if err := utils.Expand(map[string]interface{}{"name": 1}, &out); err == nil {
+		t.Fatalf("expected an error for a number in a string")
+	}
+	if err := utils.Expand(map[string]interface{}{}, out); err == nil {
+		t.Fatalf("expected an error when not given a pointer")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	expected := testEntityValue()
+	var out testEntity
+	roundTrip(t, utils.SchemaFromStruct(testEntity{}).Schema, expected, &out)
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, out)
+	}
+}
+
+func TestWorkflowRoundTrip(t *testing.T) {
+	expected := client.Workflow{
+		Name:        "workflow",
+		Description: "A workflow",
+		Tags:        []string{"test"},
+		OrgId:       "org1",
+		WorkflowDefinition: client.WorkflowDefinition{
+			Start: "a1",
+			Actions: []client.WorkflowAction{
+				{
+					Id:         "a1",
+					AppName:    "Shuffle Tools",
+					AppVersion: "1.2.0",
+					Name:       "repeat_back_to_me",
+					Parameters: []client.WorkflowParameter{{Name: "call", Value: "$exec.field"}},
+					Position:   client.WorkflowPosition{X: 10, Y: 20.5},
+				},
+			},
+			Triggers: []client.WorkflowTrigger{
+				{Id: "t1", AppName: "Webhook", TriggerType: "WEBHOOK", Parameters: []client.WorkflowParameter{}},
+			},
+			Branches: []client.WorkflowBranch{
+				{
+					Id:            "b1",
+					SourceId:      "t1",
+					DestinationId: "a1",
+					Conditions: []client.WorkflowBranchCondition{
+						{
+							Source:      client.WorkflowParameter{Value: "$exec"},
+							Condition:   client.WorkflowParameter{Value: "equals"},
+							Destination: client.WorkflowParameter{Value: "ok"},
+						},
+					},
+				},
+			},
+		},
+	}
+	var out client.Workflow
+	roundTrip(t, client.GetDefaultWorkflowSchema().Schema, expected, &out)
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, out)
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// AttributeName returns the name of the attribute of a struct field: its json name,
+// or its lowercased name when it has no json tag
+func AttributeName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name)
+}
+
+// SchemaFromStruct builds the schema of a struct from its fields, so the schema can't
+// drift from what the API returns. The fields are annotated with tags:
+//
+//	description:"The text of the attribute documentation"
+//	tf:"sensitive,required,maxitems=1"
+//
+// The tf options are sensitive, one of computed, optional or required, and maxitems=N, while
+// tf:"-" leaves the field out of the schema. Structs become lists of nested blocks,
+// embedded structs are flattened and interface{} values are kept as JSON strings.
+func SchemaFromStruct(v interface{}) *schema.Resource {
+	return schemaFromType(reflect.TypeOf(v))
+}
+
+var statusOptions = map[string]int{
+	"computed": Computed,
+	"optional": Optional,
+	"required": Required,
+}
+
+func schemaFromType(t reflect.Type) *schema.Resource {
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{},
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for key, s := range schemaFromType(f.Type).Schema {
+				r.Schema[key] = s
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		options := strings.Split(f.Tag.Get("tf"), ",")
+		if options[0] == "-" {
+			continue
+		}
+
+		s := schemaFromFieldType(f.Type)
+		if s == nil {
+			panic(fmt.Sprintf("unsupported type %s for the field %s.%s", f.Type, t.Name(), f.Name))
+		}
+		s.Description = f.Tag.Get("description")
+
+		statusOption := ""
+		for _, option := range options {
+			switch {
+			case option == "":
+			case option == "sensitive":
+				s.Sensitive = true
+			case option == "computed" || option == "optional" || option == "required":
+				if statusOption != "" {
+					panic(fmt.Sprintf("conflicting tf options %s and %s for the field %s.%s", statusOption, option, t.Name(), f.Name))
+				}
+				statusOption = option
+				setStatus(s, statusOptions[option])
+			case strings.HasPrefix(option, "maxitems="):
+				if _, err := fmt.Sscanf(option, "maxitems=%d", &s.MaxItems); err != nil {
+					panic(fmt.Sprintf("invalid tf option %s for the field %s.%s", option, t.Name(), f.Name))
+				}
+			default:
+				panic(fmt.Sprintf("unknown tf option %s for the field %s.%s", option, t.Name(), f.Name))
+			}
+		}
+
+		r.Schema[AttributeName(f)] = s
+	}
+
+	return r
+}
+
+func schemaFromFieldType(t reflect.Type) *schema.Schema {
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return &schema.Schema{Type: schema.TypeString}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return &schema.Schema{Type: schema.TypeInt}
+	case reflect.Float32, reflect.Float64:
+		return &schema.Schema{Type: schema.TypeFloat}
+	case reflect.Bool:
+		return &schema.Schema{Type: schema.TypeBool}
+	case reflect.Ptr:
+		return schemaFromFieldType(t.Elem())
+	case reflect.Struct:
+		return &schema.Schema{
+			Type: schema.TypeList,
+			Elem: schemaFromType(t),
+		}
+	case reflect.Slice:
+		elemType := t.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() == reflect.Struct {
+			return &schema.Schema{
+				Type: schema.TypeList,
+				Elem: schemaFromType(elemType),
+			}
+		}
+		elem := schemaFromFieldType(t.Elem())
+		if elem == nil || elem.Type == schema.TypeList {
+			return nil
+		}
+		return &schema.Schema{
+			Type: schema.TypeList,
+			Elem: elem,
+		}
+	case reflect.Map:
+		elem := schemaFromFieldType(t.Elem())
+		if t.Key().Kind() != reflect.String || elem == nil || elem.Type == schema.TypeList {
+			return nil
+		}
+		return &schema.Schema{
+			Type: schema.TypeMap,
+			Elem: elem,
+		}
+	}
+	return nil
+}
@@ -2,8 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"log"
-	"reflect"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -54,91 +52,35 @@ func RecurseSetSchemaStatus(r *schema.Resource, status int, parentSameStatus boo
 	return r
 }
 
+// RecurseSetSchemaStatusByKey sets the status of the attribute at the key path (i.e
+// "actions.id"), and of its parents when parentSameStatus is true. It panics when the
+// path doesn't exist, so a typo fails when the provider starts instead of leaving
+// the attribute with the wrong status.
 func RecurseSetSchemaStatusByKey(r *schema.Resource, key string, status int, parentSameStatus bool) *schema.Resource {
-	keys := strings.Split(key, ".")
+	return setSchemaStatusByKey(r, key, key, status, parentSameStatus)
+}
+
+func setSchemaStatusByKey(r *schema.Resource, path string, key string, status int, parentSameStatus bool) *schema.Resource {
+	keys := strings.SplitN(key, ".", 2)
 
-	if _, ok := r.Schema[keys[0]]; !ok {
-		fmt.Printf("[ERROR] key not found: %s\n", keys[0])
-		return r
+	s, ok := r.Schema[keys[0]]
+	if !ok {
+		panic(fmt.Sprintf("key not found: %s (in %s)", keys[0], path))
 	}
 
 	isSetStatus := true
 	if len(keys) > 1 {
-		r.Schema[keys[0]].Elem = RecurseSetSchemaStatusByKey(r.Schema[keys[0]].Elem.(*schema.Resource), strings.Join(keys[1:], "."), status, parentSameStatus)
+		elemResource, ok := s.Elem.(*schema.Resource)
+		if !ok {
+			panic(fmt.Sprintf("%s is not a block, can't find %s in it (in %s)", keys[0], keys[1], path))
+		}
+		s.Elem = setSchemaStatusByKey(elemResource, path, keys[1], status, parentSameStatus)
 		isSetStatus = parentSameStatus
 	}
 
 	if isSetStatus {
-		r.Schema[keys[0]] = setStatus(r.Schema[keys[0]], status)
+		r.Schema[keys[0]] = setStatus(s, status)
 	}
 
 	return r
 }
-
-func GenerateMapFromFields(t interface{}) map[string]interface{} {
-	temp := make(map[string]interface{})
-
-	for i := 0; i < reflect.TypeOf(t).NumField(); i++ {
-		fieldName := reflect.TypeOf(t).Field(i).Tag.Get("json")
-		if fieldName == "" {
-			fieldName = reflect.TypeOf(t).Field(i).Name
-		}
-		fieldType := reflect.TypeOf(t).Field(i).Type.String()
-		val := reflect.ValueOf(t).FieldByName(fieldName)
-
-		if !val.IsValid() {
-			log.Printf("[DEBUG] Val was invalid fieldName: %s fieldType: %s (%+v) with val %+v", fieldName, fieldType, fieldType, val)
-			continue
-		}
-
-		if (len(fieldType) >= 5 && fieldType[0:6] == "client") || (len(fieldType) >= 7 && fieldType[0:8] == "[]client") {
-			// log.Printf("[DEBUG] Got fieldName: %s fieldType: %s (%+v) with val %+v", fieldName, fieldType, fieldType, val)
-			if val.Kind() == reflect.Struct {
-				tempArray := make([]map[string]interface{}, 1)
-				newVal := GenerateMapFromFields(val.Interface())
-				if len(newVal) > 0 {
-					tempArray[0] = newVal
-					temp[strings.ToLower(fieldName)] = tempArray
-				}
-			} else if val.Kind() == reflect.Slice {
-				tempArray := make([]map[string]interface{}, val.Len())
-				atLeastOne := false
-				for i := 0; i < val.Len(); i++ {
-					newVal := GenerateMapFromFields(val.Index(i).Interface())
-					if len(newVal) > 0 {
-						atLeastOne = true
-						tempArray[i] = newVal
-					}
-				}
-				if atLeastOne {
-					temp[strings.ToLower(fieldName)] = tempArray
-				}
-			} else {
-				if val.IsNil() {
-					continue
-				}
-				newVal := GenerateMapFromFields(val.Interface())
-				if len(newVal) > 0 {
-					temp[strings.ToLower(fieldName)] = newVal
-				}
-			}
-		} else {
-			switch fieldType {
-			case
-				"string":
-				newVal := val.String()
-				if len(newVal) > 0 {
-					temp[strings.ToLower(fieldName)] = newVal
-				}
-			case "int":
-				temp[strings.ToLower(fieldName)] = val.Int()
-			case "bool":
-				temp[strings.ToLower(fieldName)] = val.Bool()
-			}
-		}
-	}
-
-	// log.Printf("[DEBUG] Temp: %+v", temp)
-
-	return temp
-}
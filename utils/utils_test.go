@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func testResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"label": {Type: schema.TypeString},
+			"fields": {
+				Type: schema.TypeMap,
+				Elem: &schema.Schema{Type: schema.TypeString},
+			},
+			"app": {
+				Type: schema.TypeList,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {Type: schema.TypeString},
+						"id":   {Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRecurseSetSchemaStatusByKey(t *testing.T) {
+	r := RecurseSetSchemaStatus(testResource(), Optional, true)
+
+	r = RecurseSetSchemaStatusByKey(r, "app.name", Required, false)
+	app := r.Schema["app"]
+	name := app.Elem.(*schema.Resource).Schema["name"]
+	if !name.Required || name.Optional || !app.Optional {
+		t.Fatalf("expected only app.name to be required, got %#v and %#v", app, name)
+	}
+
+	r = RecurseSetSchemaStatusByKey(r, "app.id", Computed, true)
+	id := app.Elem.(*schema.Resource).Schema["id"]
+	if !id.Computed || id.Optional || !app.Computed || app.Optional {
+		t.Fatalf("expected app.id and app to be computed, got %#v and %#v", app, id)
+	}
+}
+
+func TestRecurseSetSchemaStatusByKeyBadPath(t *testing.T) {
+	cases := []struct {
+		key      string
+		expected string
+	}{
+		{"missing", "key not found: missing (in missing)"},
+		{"app.missing", "key not found: missing (in app.missing)"},
+		{"fields.key", "fields is not a block, can't find key in it (in fields.key)"},
+		{"label.value", "label is not a block, can't find value in it (in label.value)"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.key, func(t *testing.T) {
+			err := catchPanic(func() {
+				RecurseSetSchemaStatusByKey(testResource(), tc.key, Required, true)
+			})
+			if err != tc.expected {
+				t.Fatalf("expected the panic %q, got %q", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestSchemaFromStructConflictingStatus(t *testing.T) {
+	type entity struct {
+		Id string `json:"id" tf:"computed,required"`
+	}
+
+	err := catchPanic(func() {
+		SchemaFromStruct(entity{})
+	})
+	if !strings.Contains(err, "conflicting tf options computed and required for the field entity.Id") {
+		t.Fatalf("expected a panic for the conflicting statuses, got %q", err)
+	}
+}
+
+func TestSchemaFromStructOptions(t *testing.T) {
+	type block struct {
+		Name string `json:"name"`
+	}
+	type entity struct {
+		Id     string  `json:"id" tf:"computed"`
+		Secret string  `json:"secret" tf:"sensitive,required"`
+		Block  block   `json:"block" tf:"optional,maxitems=1" description:"A block"`
+		Blocks []block `json:"blocks"`
+		Hidden string  `json:"hidden" tf:"-"`
+	}
+
+	s := SchemaFromStruct(entity{}).Schema
+	if !s["id"].Computed || !s["secret"].Required || !s["secret"].Sensitive || !s["block"].Optional || s["block"].MaxItems != 1 || s["block"].Description != "A block" {
+		t.Fatalf("unexpected schema %#v", s)
+	}
+	if _, ok := s["blocks"].Elem.(*schema.Resource); !ok {
+		t.Fatalf("expected blocks to be a list of blocks, got %#v", s["blocks"])
+	}
+	if _, ok := s["hidden"]; ok {
+		t.Fatalf("expected hidden to be left out")
+	}
+}
+
+// catchPanic returns the message of the panic of f, or an empty string when it doesn't panic
+func catchPanic(f func()) (message string) {
+	defer func() {
+		if r := recover(); r != nil {
+			message = fmt.Sprint(r)
+		}
+	}()
+	f()
+	return ""
+}